@@ -0,0 +1,676 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the store of API keys used to authenticate
+ * machine clients.
+ */
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `APIKeyPrefix` is the leading marker of all API keys.
+	APIKeyPrefix = "pl_"
+
+	// Length (in bytes) of an API key's identifying prefix.
+	apiKeyIDLen = 4
+
+	// Length (in bytes) of an API key's secret part.
+	apiKeySecretLen = 32
+)
+
+var (
+	// `ErrKeyExpired` is returned (and passed to [THooks.OnFailure])
+	// if an API key's expiry time has passed.
+	ErrKeyExpired = errors.New("API key expired")
+)
+
+type (
+	// `TAPIKey` describes a single API key (without its secret).
+	TAPIKey struct {
+		Prefix  string    // the key's public identifying prefix
+		Owner   string    // the name of the key's owner
+		Scopes  []string  // the scopes granted to the key
+		Created time.Time // the time the key was generated
+		Expires time.Time // the key's expiry time (zero: never)
+		digest  string    // hex encoded SHA-256 of the whole key
+	}
+
+	// `TAPIKeys` is a list of API keys for machine clients.
+	//
+	// The keys are high-entropy random strings of the form
+	// "pl_<prefix>_<secret>"; only their SHA-256 digests are stored,
+	// so the key itself is shown just once when it's generated. The
+	// (public) prefix identifies the key, e.g. in logs or to revoke it.
+	//
+	// The file format is one key per line with the fields
+	// "prefix:owner:scopes:created:expires:sha256" separated by colons
+	// (the scopes separated by commas, the times as Unix seconds with
+	// zero meaning "never"); empty lines and comments (starting with
+	// `#` or `;`) are skipped.
+	TAPIKeys struct {
+		mtx      sync.RWMutex        // protect concurrent access
+		filename string              // name of the key file
+		entries  map[string]*TAPIKey // list of keys by prefix
+	}
+)
+
+// `LoadAPIKeys()` returns a new `TAPIKeys` instance with the
+// contents of `aFilename`.
+//
+// Parameters:
+//   - `aFilename`: Name of the key file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TAPIKeys`: A new `TAPIKeys` instance.
+//   - `error`: A possible error during processing the request.
+func LoadAPIKeys(aFilename string) (*TAPIKeys, error) {
+	ak := NewAPIKeys(aFilename)
+	if nil == ak {
+		return nil, se.New(errors.New(`missing/empty file name`), 2)
+	}
+
+	return ak, ak.Load()
+} // LoadAPIKeys()
+
+// `NewAPIKeys()` returns a new `TAPIKeys` instance.
+//
+// If `aFilename` is empty the function returns `nil`.
+//
+// Parameters:
+//   - `aFilename`: Name of the key file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TAPIKeys`: A new `TAPIKeys` instance.
+func NewAPIKeys(aFilename string) *TAPIKeys {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		return nil
+	}
+
+	return &TAPIKeys{
+		filename: aFilename,
+		entries:  make(map[string]*TAPIKey, 16),
+	}
+} // NewAPIKeys()
+
+// --------------------------------------------------------------------------
+// `TAPIKey` methods:
+
+// `clone()` returns a copy of the key's description.
+//
+// Returns:
+//   - `TAPIKey`: The copy (sharing nothing with the original).
+func (k TAPIKey) clone() TAPIKey {
+	result := k
+	result.Scopes = slices.Clone(k.Scopes)
+
+	return result
+} // clone()
+
+// `Expired()` returns whether the key's expiry time has passed.
+//
+// Parameters:
+//   - `aNow`: The time to check against.
+//
+// Returns:
+//   - `bool`: `true` if the key is expired, or `false` otherwise.
+func (k TAPIKey) Expired(aNow time.Time) bool {
+	return !k.Expires.IsZero() && !aNow.Before(k.Expires)
+} // Expired()
+
+// `HasScope()` returns whether the key grants `aScope`.
+//
+// Parameters:
+//   - `aScope`: The scope to check.
+//
+// Returns:
+//   - `bool`: `true` if the key has the scope, or `false` otherwise.
+func (k TAPIKey) HasScope(aScope string) bool {
+	return slices.Contains(k.Scopes, aScope)
+} // HasScope()
+
+// `String()` returns the key's description as a single line.
+//
+// Returns:
+//   - `string`: The key's prefix, owner, scopes and times.
+func (k TAPIKey) String() string {
+	expires := "never"
+	if !k.Expires.IsZero() {
+		expires = k.Expires.Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf("%s\t%s\t[%s]\tcreated %s\texpires %s",
+		k.Prefix, k.Owner, strings.Join(k.Scopes, ","),
+		k.Created.Format(time.RFC3339), expires)
+} // String()
+
+// --------------------------------------------------------------------------
+// `TAPIKeys` methods:
+
+// `Clear()` empties the list.
+//
+// Returns:
+//   - `*TAPIKeys`: The cleared list.
+func (ak *TAPIKeys) Clear() *TAPIKeys {
+	ak.mtx.Lock()
+	clear(ak.entries)
+	ak.mtx.Unlock()
+
+	return ak
+} // Clear()
+
+// `Exists()` returns whether there's a key with `aPrefix`.
+//
+// Parameters:
+//   - `aPrefix`: The key's identifying prefix.
+//
+// Returns:
+//   - `bool`: `true` if the key exists, or `false` otherwise.
+func (ak *TAPIKeys) Exists(aPrefix string) bool {
+	_, err := ak.Find(aPrefix)
+
+	return nil == err
+} // Exists()
+
+// `Find()` returns the description of the key with `aPrefix`.
+//
+// Parameters:
+//   - `aPrefix`: The key's identifying prefix.
+//
+// Returns:
+//   - `TAPIKey`: The key's description.
+//   - `error`: A possible error during processing the request.
+func (ak *TAPIKeys) Find(aPrefix string) (TAPIKey, error) {
+	if nil == ak {
+		return TAPIKey{}, se.New(errors.New("missing API key list"), 1)
+	}
+
+	ak.mtx.RLock()
+	entry, ok := ak.entries[aPrefix]
+	ak.mtx.RUnlock()
+	if !ok {
+		return TAPIKey{}, se.New(fmt.Errorf("API key '%s' not found", aPrefix), 2)
+	}
+
+	return entry.clone(), nil
+} // Find()
+
+// `Generate()` creates a new API key for `aOwner`.
+//
+// The returned key is the only copy of the secret; it can't be
+// recovered from the list later on.
+//
+// Parameters:
+//   - `aOwner`: The name of the key's owner.
+//   - `aScopes`: The scopes to grant to the key.
+//   - `aExpires`: The key's expiry time (zero: never).
+//
+// Returns:
+//   - `string`: The new API key.
+//   - `TAPIKey`: The key's description.
+//   - `error`: A possible error during processing the request.
+func (ak *TAPIKeys) Generate(aOwner string, aScopes []string, aExpires time.Time) (string, TAPIKey, error) {
+	if err := checkDigestName(aOwner, "owner"); nil != err {
+		return "", TAPIKey{}, se.New(err, 1)
+	}
+	scopes := make([]string, 0, len(aScopes))
+	for _, scope := range aScopes {
+		if scope = strings.TrimSpace(scope); "" == scope {
+			continue
+		}
+		if strings.ContainsAny(scope, ":, \t\r\n") {
+			return "", TAPIKey{}, se.New(fmt.Errorf("scope '%s' contains invalid characters", scope), 2)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	ak.mtx.Lock()
+	defer ak.mtx.Unlock()
+
+	var prefix, secret string
+	for {
+		buf := make([]byte, apiKeyIDLen+apiKeySecretLen)
+		if _, err := rand.Read(buf); nil != err {
+			return "", TAPIKey{}, se.New(err, 2)
+		}
+		prefix = hex.EncodeToString(buf[:apiKeyIDLen])
+		secret = hex.EncodeToString(buf[apiKeyIDLen:])
+		if _, ok := ak.entries[prefix]; !ok {
+			break
+		}
+	}
+	key := APIKeyPrefix + prefix + "_" + secret
+
+	entry := &TAPIKey{
+		Prefix:  prefix,
+		Owner:   aOwner,
+		Scopes:  scopes,
+		Created: unixTime(time.Now().Unix()),
+		Expires: unixTime(aExpires.Unix()),
+		digest:  apiKeyDigest(key),
+	}
+	ak.entries[prefix] = entry
+
+	return key, entry.clone(), nil
+} // Generate()
+
+// `Len()` returns the number of keys in the list.
+//
+// Returns:
+//   - `int`: The number of keys.
+func (ak *TAPIKeys) Len() int {
+	ak.mtx.RLock()
+	defer ak.mtx.RUnlock()
+
+	return len(ak.entries)
+} // Len()
+
+// `List()` returns the descriptions of all keys sorted by owner
+// and prefix.
+//
+// Returns:
+//   - `[]TAPIKey`: The list of keys.
+func (ak *TAPIKeys) List() []TAPIKey {
+	ak.mtx.RLock()
+	result := make([]TAPIKey, 0, len(ak.entries))
+	for _, entry := range ak.entries {
+		result = append(result, entry.clone())
+	}
+	ak.mtx.RUnlock()
+	slices.SortFunc(result, func(a, b TAPIKey) int {
+		if c := strings.Compare(a.Owner, b.Owner); 0 != c {
+			return c
+		}
+		return strings.Compare(a.Prefix, b.Prefix)
+	})
+
+	return result
+} // List()
+
+// `Load()` reads the key file replacing the list's current contents.
+//
+// If the file contains a line that's not an API key record (e.g.
+// because it's a password file) an error is returned and the list's
+// contents remain unchanged.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ak *TAPIKeys) Load() error {
+	if "" == ak.filename {
+		return se.New(errors.New("missing/empty filename"), 1)
+	}
+
+	file, err := os.Open(ak.filename)
+	if nil != err {
+		return se.New(err, 2)
+	}
+	defer file.Close()
+
+	entries, err := ak.read(file)
+	if nil != err {
+		return err // already wrapped
+	}
+
+	ak.mtx.Lock()
+	ak.entries = entries
+	ak.mtx.Unlock()
+
+	return nil
+} // Load()
+
+// `read()` parses the API key records of `aFile`.
+//
+// Parameters:
+//   - `aFile`: The opened key file.
+//
+// Returns:
+//   - `map[string]*TAPIKey`: The file's keys by prefix.
+//   - `error`: A possible error during processing the request.
+func (ak *TAPIKeys) read(aFile *os.File) (map[string]*TAPIKey, error) {
+	entries := make(map[string]*TAPIKey, 16)
+	scanner := bufio.NewScanner(aFile)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if (0 == len(line)) || (';' == line[0]) || ('#' == line[0]) {
+			// Skip blank and comment lines
+			continue
+		}
+
+		entry, ok := parseAPIKeyLine(line)
+		if !ok {
+			return nil, se.New(fmt.Errorf("%q line %d: not an API key record", ak.filename, lineNo), 2)
+		}
+		entries[entry.Prefix] = entry
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, se.New(err, 2)
+	}
+
+	return entries, nil
+} // read()
+
+// `Remove()` deletes (i.e. revokes) the key with `aPrefix`.
+//
+// Parameters:
+//   - `aPrefix`: The key's identifying prefix.
+//
+// Returns:
+//   - `*TAPIKeys`: The updated list.
+func (ak *TAPIKeys) Remove(aPrefix string) *TAPIKeys {
+	ak.mtx.Lock()
+	delete(ak.entries, aPrefix)
+	ak.mtx.Unlock()
+
+	return ak
+} // Remove()
+
+// `Store()` writes the list's contents to the key file.
+//
+// An existing file containing a line that's not an API key record
+// (e.g. because it's a password file) is left untouched and an
+// error is returned instead.
+//
+// Returns:
+//   - `int`: The number of bytes written.
+//   - `error`: A possible error during processing the request.
+func (ak *TAPIKeys) Store() (int, error) {
+	if "" == ak.filename {
+		return 0, se.New(errors.New("missing/empty filename"), 1)
+	}
+	if file, err := os.Open(ak.filename); nil == err {
+		_, err = ak.read(file)
+		_ = file.Close()
+		if nil != err {
+			return 0, err // already wrapped
+		}
+	}
+	s := []byte(ak.String())
+
+	file, err := os.OpenFile(ak.filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G302
+	if nil != err {
+		return 0, se.New(err, 2)
+	}
+	defer file.Close()
+
+	return file.Write(s)
+} // Store()
+
+// `String()` returns the list in file format as a single,
+// LF-separated string.
+//
+// Returns:
+//   - `string`: A stringified representation of the list.
+func (ak *TAPIKeys) String() string {
+	ak.mtx.RLock()
+	list := make([]string, 0, len(ak.entries))
+	for _, entry := range ak.entries {
+		list = append(list, entry.Prefix+":"+entry.Owner+":"+
+			strings.Join(entry.Scopes, ",")+":"+
+			unixSeconds(entry.Created)+":"+unixSeconds(entry.Expires)+
+			":"+entry.digest)
+	}
+	ak.mtx.RUnlock()
+	if 0 == len(list) {
+		return ""
+	}
+	slices.Sort(list)
+
+	return strings.Join(list, "\n") + "\n"
+} // String()
+
+// `Verify()` checks `aKey` and returns its description.
+//
+// Parameters:
+//   - `aKey`: The API key sent by a client.
+//
+// Returns:
+//   - `TAPIKey`: The key's description.
+//   - `error`: [ErrInvalidCredentials], [ErrKeyExpired], or `nil`.
+func (ak *TAPIKeys) Verify(aKey string) (TAPIKey, error) {
+	prefix, ok := apiKeyID(aKey)
+	if !ok || (nil == ak) {
+		return TAPIKey{}, ErrInvalidCredentials
+	}
+
+	digest := apiKeyDigest(aKey)
+
+	ak.mtx.RLock()
+	entry, ok := ak.entries[prefix]
+	ak.mtx.RUnlock()
+	if !ok {
+		return TAPIKey{}, ErrInvalidCredentials
+	}
+	if 1 != subtle.ConstantTimeCompare([]byte(digest), []byte(entry.digest)) {
+		return TAPIKey{}, ErrInvalidCredentials
+	}
+	if entry.Expired(time.Now()) {
+		return entry.clone(), ErrKeyExpired
+	}
+
+	return entry.clone(), nil
+} // Verify()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `apiKeyDigest()` returns the hex encoded SHA-256 digest of `aKey`.
+//
+// Parameters:
+//   - `aKey`: The API key to hash.
+//
+// Returns:
+//   - `string`: The key's digest.
+func apiKeyDigest(aKey string) string {
+	sum := sha256.Sum256([]byte(aKey))
+
+	return hex.EncodeToString(sum[:])
+} // apiKeyDigest()
+
+// `apiKeyFromRequest()` returns the API key sent with `aRequest`.
+//
+// The key is taken from the header `aHeader` (if not empty) or
+// from a "Bearer" token in the header `aAuthHeader`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aAuthHeader`: The name of the authorisation header.
+//   - `aHeader`: The name of a dedicated API key header (may be empty).
+//
+// Returns:
+//   - `string`: The API key sent.
+//   - `bool`: `true` if the request carries an API key.
+func apiKeyFromRequest(aRequest *http.Request, aAuthHeader, aHeader string) (string, bool) {
+	if "" != aHeader {
+		if key := strings.TrimSpace(aRequest.Header.Get(aHeader)); "" != key {
+			return key, true
+		}
+	}
+	token, ok := bearerToken(aRequest, aAuthHeader)
+	if !ok || !strings.HasPrefix(token, APIKeyPrefix) {
+		return "", false // some other kind of token
+	}
+
+	return token, true
+} // apiKeyFromRequest()
+
+// `apiKeyID()` returns the identifying prefix of `aKey`.
+//
+// Parameters:
+//   - `aKey`: The API key to check.
+//
+// Returns:
+//   - `string`: The key's prefix.
+//   - `bool`: `true` if `aKey` is well-formed.
+func apiKeyID(aKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(aKey, APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || (2*apiKeyIDLen != len(prefix)) || (2*apiKeySecretLen != len(secret)) {
+		return "", false
+	}
+
+	return prefix, true
+} // apiKeyID()
+
+// `bearerChallenge()` returns the "Bearer" challenge for `aRealm`
+// as defined by RFC 6750.
+//
+// Parameters:
+//   - `aRealm`: The name of the protected domain.
+//   - `aInvalid`: Whether the request carried an invalid token.
+//
+// Returns:
+//   - `string`: The challenge to send.
+func bearerChallenge(aRealm string, aInvalid bool) string {
+	result := `Bearer realm=` + quoteParam(aRealm)
+	if aInvalid {
+		result += `, error="invalid_token"`
+	}
+
+	return result
+} // bearerChallenge()
+
+// `bearerToken()` returns the "Bearer" token sent in the header
+// `aAuthHeader` of `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aAuthHeader`: The name of the authorisation header.
+//
+// Returns:
+//   - `string`: The token sent.
+//   - `bool`: `true` if the request carries a Bearer token.
+func bearerToken(aRequest *http.Request, aAuthHeader string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(aRequest.Header.Get(aAuthHeader)), " ")
+	if !ok || !strings.EqualFold("Bearer", scheme) {
+		return "", false
+	}
+	if token = strings.TrimSpace(token); "" == token {
+		return "", false
+	}
+
+	return token, true
+} // bearerToken()
+
+// `keyPrincipal()` returns a new principal authenticated by `aKey`.
+//
+// Parameters:
+//   - `aKey`: The verified API key.
+//
+// Returns:
+//   - `*TPrincipal`: The new principal.
+func keyPrincipal(aKey TAPIKey) *TPrincipal {
+	p := newPrincipal(aKey.Owner, AuthAPIKey)
+	p.Scopes = aKey.Scopes
+	p.Attributes = map[string]string{"key": aKey.Prefix}
+
+	return p
+} // keyPrincipal()
+
+// `parseAPIKeyLine()` parses a single line of a key file.
+//
+// Parameters:
+//   - `aLine`: The (non-empty, non-comment) line to parse.
+//
+// Returns:
+//   - `*TAPIKey`: The parsed key record.
+//   - `bool`: `true` if the line is a valid key record.
+func parseAPIKeyLine(aLine string) (*TAPIKey, bool) {
+	parts := strings.Split(aLine, ":")
+	if 6 != len(parts) {
+		return nil, false
+	}
+	prefix, owner := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if ("" == prefix) || ("" == owner) {
+		return nil, false
+	}
+	digest := strings.ToLower(strings.TrimSpace(parts[5]))
+	if !isHexHash(digest, sha256.Size) {
+		return nil, false
+	}
+	created, err := strconv.ParseInt(strings.TrimSpace(parts[3]), 10, 64)
+	if nil != err {
+		return nil, false
+	}
+	expires, err := strconv.ParseInt(strings.TrimSpace(parts[4]), 10, 64)
+	if nil != err {
+		return nil, false // better no key than one that never expires
+	}
+
+	entry := &TAPIKey{
+		Prefix:  prefix,
+		Owner:   owner,
+		Created: unixTime(created),
+		Expires: unixTime(expires),
+		digest:  digest,
+	}
+	for _, scope := range strings.Split(parts[2], ",") {
+		if scope = strings.TrimSpace(scope); "" != scope {
+			entry.Scopes = append(entry.Scopes, scope)
+		}
+	}
+
+	return entry, true
+} // parseAPIKeyLine()
+
+// `unixSeconds()` returns `aTime` as decimal Unix seconds
+// (zero for the zero time).
+//
+// Parameters:
+//   - `aTime`: The time to convert.
+//
+// Returns:
+//   - `string`: The decimal number of seconds.
+func unixSeconds(aTime time.Time) string {
+	if aTime.IsZero() {
+		return "0"
+	}
+
+	return strconv.FormatInt(aTime.Unix(), 10)
+} // unixSeconds()
+
+// `unixTime()` returns the time of `aSeconds` Unix seconds (the
+// zero time for zero).
+//
+// Parameters:
+//   - `aSeconds`: The number of seconds to convert.
+//
+// Returns:
+//   - `time.Time`: The UTC time.
+func unixTime(aSeconds int64) time.Time {
+	if 0 >= aSeconds {
+		return time.Time{}
+	}
+
+	return time.Unix(aSeconds, 0).UTC()
+} // unixTime()
+
+/* _EoF_ */
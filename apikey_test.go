@@ -0,0 +1,240 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_apiKeyFromRequest(t *testing.T) {
+	key := APIKeyPrefix + "0123abcd_" + strings.Repeat("ab", apiKeySecretLen)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		keyHdr string
+		want   string
+		wantOK bool
+	}{
+		{" 1", "Authorization", "Bearer " + key, "", key, true},
+		{" 2", "Authorization", "bearer  " + key, "", key, true},
+		{" 3", "Authorization", "Bearer eyJhbGciOi.x.y", "", "", false},
+		{" 4", "Authorization", "Basic dXNlcjpwYXNz", "", "", false},
+		{" 5", "X-Api-Key", key, "X-Api-Key", key, true},
+		{" 6", "X-Api-Key", key, "", "", false},
+		{" 7", "", "", "X-Api-Key", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			if "" != tt.header {
+				req.Header.Set(tt.header, tt.value)
+			}
+			got, ok := apiKeyFromRequest(req, "Authorization", tt.keyHdr)
+			if (got != tt.want) || (ok != tt.wantOK) {
+				t.Errorf("apiKeyFromRequest() = %q, %v, want %q, %v",
+					got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+} // Test_apiKeyFromRequest()
+
+func Test_TAPIKeys_Verify(t *testing.T) {
+	ak := NewAPIKeys(filepath.Join(t.TempDir(), "keys.db"))
+	k1, info, err := ak.Generate("service1", []string{"read", " write", "read", ""}, time.Time{})
+	if nil != err {
+		t.Fatalf("TAPIKeys.Generate() error = %v", err)
+	}
+	if 2 != len(info.Scopes) {
+		t.Errorf("TAPIKeys.Generate() scopes = %v, want [read write]", info.Scopes)
+	}
+	k2, _, _ := ak.Generate("service2", nil, time.Now().Add(-time.Minute))
+	// change the key's last digit to get a wrong secret:
+	last := "0"
+	if strings.HasSuffix(k1, last) {
+		last = "1"
+	}
+	if _, _, err = ak.Generate("bad:owner", nil, time.Time{}); nil == err {
+		t.Error("TAPIKeys.Generate() expected error for invalid owner")
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		owner   string
+		wantErr error
+	}{
+		{" 1", k1, "service1", nil},
+		{" 2", k2, "service2", ErrKeyExpired},
+		{" 3", k1[:len(k1)-1] + last, "", ErrInvalidCredentials},
+		{" 4", APIKeyPrefix + "00000000_" + strings.Repeat("0", 2*apiKeySecretLen), "", ErrInvalidCredentials},
+		{" 5", "pl_short", "", ErrInvalidCredentials},
+		{" 6", "", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ak.Verify(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TAPIKeys.Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Owner != tt.owner {
+				t.Errorf("TAPIKeys.Verify() owner = %q, want %q", got.Owner, tt.owner)
+			}
+		})
+	}
+} // Test_TAPIKeys_Verify()
+
+func Test_TAPIKeys_Store(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "keys.db")
+	ak := NewAPIKeys(fn)
+	k1, info1, _ := ak.Generate("service1", []string{"read", "write"}, time.Now().Add(time.Hour))
+	k2, info2, _ := ak.Generate("service2", nil, time.Time{})
+	if _, err := ak.Store(); nil != err {
+		t.Fatalf("TAPIKeys.Store() error = %v", err)
+	}
+
+	got, err := LoadAPIKeys(fn)
+	if nil != err {
+		t.Fatalf("LoadAPIKeys() error = %v", err)
+	}
+	if got.String() != ak.String() {
+		t.Errorf("LoadAPIKeys() = %q, want %q", got.String(), ak.String())
+	}
+	if k, err := got.Verify(k1); (nil != err) || !k.Expires.Equal(info1.Expires) || !k.HasScope("write") {
+		t.Errorf("TAPIKeys.Verify() = %v, %v, want %v", k, err, info1)
+	}
+	if k, err := got.Verify(k2); (nil != err) || !k.Expires.IsZero() {
+		t.Errorf("TAPIKeys.Verify() = %v, %v, want %v", k, err, info2)
+	}
+
+	got.Remove(info1.Prefix)
+	if got.Exists(info1.Prefix) || (1 != got.Len()) {
+		t.Errorf("TAPIKeys.Remove() didn't remove %q", info1.Prefix)
+	}
+	if _, err := got.Verify(k1); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("TAPIKeys.Verify() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+} // Test_TAPIKeys_Store()
+
+func Test_TAPIKeys_foreignFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "pwaccess.db")
+	pwFile := "user1:$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy\n"
+	if err := os.WriteFile(fn, []byte(pwFile), 0600); nil != err {
+		t.Fatal(err)
+	}
+
+	ak := NewAPIKeys(fn)
+	if err := ak.Load(); nil == err {
+		t.Error("TAPIKeys.Load() accepted a password file")
+	}
+	if _, _, err := ak.Generate("service1", nil, time.Time{}); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := ak.Store(); nil == err {
+		t.Error("TAPIKeys.Store() overwrote a password file")
+	}
+	if got, _ := os.ReadFile(fn); pwFile != string(got) {
+		t.Errorf("password file = %q, want %q", got, pwFile)
+	}
+} // Test_TAPIKeys_foreignFile()
+
+func Test_bearerChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		realm   string
+		invalid bool
+		want    string
+	}{
+		{" 1", "My Site", false, `Bearer realm="My Site"`},
+		{" 2", "My Site", true, `Bearer realm="My Site", error="invalid_token"`},
+		{" 3", `say "hi"`, false, `Bearer realm="say \"hi\""`},
+		{" 4", "evil\r\nX-Injected: 1", false, `Bearer realm="evilX-Injected: 1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bearerChallenge(tt.realm, tt.invalid); got != tt.want {
+				t.Errorf("bearerChallenge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+} // Test_bearerChallenge()
+
+func Test_TMiddleware_apiKeys(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	ak := NewAPIKeys(filepath.Join(t.TempDir(), "keys.db"))
+	key, _, _ := ak.Generate("service1", []string{"read"}, time.Time{})
+
+	var principal *TPrincipal
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithRealm("test"),
+		WithAPIKeys(ak, "x-api-key"),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+		aWriter.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		header    string
+		value     string
+		want      int
+		wantOwner string
+		wantAuth  string
+	}{
+		{" 1", "Authorization", "Bearer " + key, http.StatusOK, "service1", ""},
+		{" 2", "X-API-Key", key, http.StatusOK, "service1", ""},
+		{" 3", "Authorization", "Bearer " + key[:len(key)-2] + "xx", http.StatusUnauthorized, "",
+			`Bearer realm="test", error="invalid_token"|Basic realm="test", charset="UTF-8"`},
+		{" 4", "", "", http.StatusUnauthorized, "",
+			`Bearer realm="test"|Basic realm="test", charset="UTF-8"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			if "" != tt.header {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, tt.want)
+			}
+			if "" == tt.wantOwner {
+				if got := strings.Join(rec.Header().Values("WWW-Authenticate"), "|"); got != tt.wantAuth {
+					t.Errorf("TMiddleware.Wrap() challenges = %q, want %q", got, tt.wantAuth)
+				}
+				return
+			}
+			if (nil == principal) || (principal.Name != tt.wantOwner) ||
+				(AuthAPIKey != principal.AuthMethod) || !principal.HasScope("read") {
+				t.Errorf("TMiddleware.Wrap() principal = %+v, want owner %q",
+					principal, tt.wantOwner)
+			}
+		})
+	}
+
+	// Basic authentication still works:
+	if rec := serve(handler, u1, p1); http.StatusOK != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+} // Test_TMiddleware_apiKeys()
+
+/* _EoF_ */
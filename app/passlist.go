@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	ul "github.com/mwat56/passlist"
 )
@@ -26,22 +27,34 @@ type (
 // `getArguments()` reads the commandline arguments and returns a list of them.
 func getArguments() tArgumentList {
 	var (
-		fileStr, addStr, chkStr, delStr, updStr string
-		lstBool, quietBool                      bool
+		fileStr, addStr, chkStr, delStr, digestStr, updStr string
+		digestFileStr, keysStr, scopesStr                  string
+		lstBool, quietBool                                 bool
+		ttlDur                                             time.Duration
 	)
 
+	flag.CommandLine.StringVar(&keysStr, "apikeys", "",
+		"<filename> maintain that API key file instead of the password file\n(-add <owner>, -chk/-del/-upd <prefix>, -lst)")
 	flag.CommandLine.StringVar(&addStr, "add", "",
 		"<username> name of the user to add to the file (prompting for the password)")
 	flag.CommandLine.StringVar(&chkStr, "chk", "",
 		"<username> name of the user whose pass to check (prompting for the password)")
 	flag.CommandLine.StringVar(&delStr, "del", "",
 		"<username> name of the user to remove from the file")
+	flag.CommandLine.StringVar(&digestStr, "digest", "",
+		"<realm> maintain the Digest HA1 file (see -digestfile) for that realm instead of the password file")
+	flag.CommandLine.StringVar(&digestFileStr, "digestfile", "./.digest.db",
+		"<filename> name of the Digest HA1 file to use with -digest")
 	flag.CommandLine.StringVar(&fileStr, "file", "./.pwaccess.db",
 		"<filename> name of the passwordfile to use")
 	flag.CommandLine.BoolVar(&lstBool, "lst", false,
 		"list all current usernames from the list")
 	flag.CommandLine.BoolVar(&quietBool, "q", false,
 		"whether to be quiet or not (suppress screen output)")
+	flag.CommandLine.StringVar(&scopesStr, "scopes", "",
+		"<scope,...> comma separated scopes of a new API key")
+	flag.CommandLine.DurationVar(&ttlDur, "ttl", 0,
+		"<duration> lifetime of a new API key (e.g. 720h; default: never expiring)")
 	flag.CommandLine.StringVar(&updStr, "upd", "",
 		"<username> name of the user to update in the file (prompting for the password)")
 
@@ -56,18 +69,35 @@ func getArguments() tArgumentList {
 	if 0 < len(addStr) {
 		result["add"] = addStr
 	}
+	if 0 < len(keysStr) {
+		keysStr, _ = filepath.Abs(keysStr)
+		result["apikeys"] = keysStr
+	}
 	if 0 < len(chkStr) {
 		result["chk"] = chkStr
 	}
 	if 0 < len(delStr) {
 		result["del"] = delStr
 	}
+	if 0 < len(digestStr) {
+		result["digest"] = digestStr
+	}
+	if 0 < len(digestFileStr) {
+		digestFileStr, _ = filepath.Abs(digestFileStr)
+		result["digestfile"] = digestFileStr
+	}
 	if lstBool {
 		result["lst"] = "true"
 	}
 	if quietBool {
 		result["quiet"] = "true"
 	}
+	if 0 < len(scopesStr) {
+		result["scopes"] = scopesStr
+	}
+	if 0 < ttlDur {
+		result["ttl"] = ttlDur.String()
+	}
 	if 0 < len(updStr) {
 		result["upd"] = updStr
 	}
@@ -81,6 +111,15 @@ func run(aArgs tArgumentList) {
 	}
 	fn := aArgs["filename"]
 
+	if keyfile, ok := aArgs["apikeys"]; ok {
+		runAPIKeys(aArgs, keyfile)
+		return
+	}
+	if realm, ok := aArgs["digest"]; ok {
+		runDigest(aArgs, realm, aArgs["digestfile"])
+		return
+	}
+
 	if adduser, ok := aArgs["add"]; ok {
 		ul.AddUser(adduser, fn)
 	}
@@ -102,6 +141,54 @@ func run(aArgs tArgumentList) {
 	}
 } // run()
 
+// `runAPIKeys()` maintains the API key file `aFilename`.
+func runAPIKeys(aArgs tArgumentList, aFilename string) {
+	ttl, _ := time.ParseDuration(aArgs["ttl"])
+
+	if owner, ok := aArgs["add"]; ok {
+		ul.AddAPIKey(owner, aArgs["scopes"], ttl, aFilename)
+	}
+
+	if prefix, ok := aArgs["chk"]; ok {
+		ul.CheckAPIKey(prefix, aFilename)
+	}
+
+	if prefix, ok := aArgs["del"]; ok {
+		ul.DeleteAPIKey(prefix, aFilename)
+	}
+
+	if lst, ok := aArgs["lst"]; ok && ("true" == lst) {
+		ul.ListAPIKeys(aFilename)
+	}
+
+	if prefix, ok := aArgs["upd"]; ok {
+		ul.UpdateAPIKey(prefix, ttl, aFilename)
+	}
+} // runAPIKeys()
+
+// `runDigest()` maintains the Digest HA1 file `aFilename` for `aRealm`.
+func runDigest(aArgs tArgumentList, aRealm, aFilename string) {
+	if adduser, ok := aArgs["add"]; ok {
+		ul.AddDigestUser(adduser, aRealm, aFilename)
+	}
+
+	if chkuser, ok := aArgs["chk"]; ok {
+		ul.CheckDigestUser(chkuser, aRealm, aFilename)
+	}
+
+	if deluser, ok := aArgs["del"]; ok {
+		ul.DeleteDigestUser(deluser, aRealm, aFilename)
+	}
+
+	if lst, ok := aArgs["lst"]; ok && ("true" == lst) {
+		ul.ListDigestUsers(aFilename)
+	}
+
+	if upduser, ok := aArgs["upd"]; ok {
+		ul.UpdateDigestUser(upduser, aRealm, aFilename)
+	}
+} // runDigest()
+
 // `showHelp()` lists the commandline options to `Stderr`.
 func showHelp() {
 	fmt.Fprintf(os.Stderr, "\nUsage: %s [OPTIONS]\n\n", os.Args[0])
@@ -0,0 +1,209 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package main
+
+/*
+ * This program runs a reverse proxy authenticating all requests
+ * before forwarding them to an upstream server.
+ */
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	ul "github.com/mwat56/passlist"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+type (
+	// `tArgumentList` is the list type returned by `getArguments()`
+	// to deliver the actual commandline arguments back to the caller.
+	tArgumentList map[string]string
+)
+
+// `getArguments()` reads the commandline arguments and returns a list of them.
+func getArguments() tArgumentList {
+	var (
+		certStr, fileStr, headerStr, keyStr, listenStr, realmStr, upstreamStr string
+		reloadDur                                                             time.Duration
+	)
+
+	flag.CommandLine.StringVar(&certStr, "cert", "",
+		"<filename> name of the TLS certificate file (requires -key)")
+	flag.CommandLine.StringVar(&fileStr, "file", "./.pwaccess.db",
+		"<filename> name of the passwordfile to use")
+	flag.CommandLine.StringVar(&headerStr, "header", "X-Remote-User",
+		"<name> name of the header passing the authenticated user upstream")
+	flag.CommandLine.StringVar(&keyStr, "key", "",
+		"<filename> name of the TLS private key file (requires -cert)")
+	flag.CommandLine.StringVar(&listenStr, "listen", ":8080",
+		"<address> the address to listen on")
+	flag.CommandLine.StringVar(&realmStr, "realm", "",
+		"<name> name of the protected realm (default: upstream host)")
+	flag.CommandLine.DurationVar(&reloadDur, "reload", time.Minute,
+		"<duration> interval to check the passwordfile for changes (0 = never)")
+	flag.CommandLine.StringVar(&upstreamStr, "upstream", "",
+		"<URL> the server to forward authenticated requests to")
+
+	flag.CommandLine.Usage = showHelp
+	flag.CommandLine.Parse(os.Args[1:]) //#nosec G104
+
+	result := make(tArgumentList)
+	if 0 < len(certStr) {
+		certStr, _ = filepath.Abs(certStr)
+		result["cert"] = certStr
+	}
+	if 0 < len(fileStr) {
+		fileStr, _ = filepath.Abs(fileStr)
+		result["filename"] = fileStr
+	}
+	if headerStr = strings.TrimSpace(headerStr); 0 < len(headerStr) {
+		result["header"] = http.CanonicalHeaderKey(headerStr)
+	}
+	if 0 < len(keyStr) {
+		keyStr, _ = filepath.Abs(keyStr)
+		result["key"] = keyStr
+	}
+	if 0 < len(listenStr) {
+		result["listen"] = listenStr
+	}
+	if 0 < len(realmStr) {
+		result["realm"] = realmStr
+	}
+	if 0 < reloadDur {
+		result["reload"] = reloadDur.String()
+	}
+	if 0 < len(upstreamStr) {
+		result["upstream"] = upstreamStr
+	}
+
+	return result
+} // getArguments()
+
+// `newProxy()` returns a reverse proxy forwarding all requests to
+// `aUpstream`.
+//
+// The proxy removes the client's `Authorization` header and any
+// client supplied `aHeader`, and sets `aHeader` to the name of the
+// authenticated user. If `aHeader` is empty no user is passed on.
+func newProxy(aUpstream *url.URL, aHeader string) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(aRequest *httputil.ProxyRequest) {
+			aRequest.SetURL(aUpstream)
+			aRequest.SetXForwarded()
+
+			// Don't pass the credentials upstream:
+			aRequest.Out.Header.Del("Authorization")
+			aRequest.Out.URL.User = nil
+
+			if "" == aHeader {
+				return
+			}
+			// Don't trust a header sent by the client:
+			aRequest.Out.Header.Del(aHeader)
+			if user := ul.UserFromRequest(aRequest.In); "" != user {
+				aRequest.Out.Header.Set(aHeader, user)
+			}
+		},
+	}
+} // newProxy()
+
+// `run()` is the main program, externalised for easier testing.
+func run(aArgs tArgumentList) error {
+	upstream, err := url.Parse(aArgs["upstream"])
+	if (nil != err) || !upstream.IsAbs() || ("" == upstream.Host) {
+		return fmt.Errorf("invalid upstream URL '%s'", aArgs["upstream"])
+	}
+	header := aArgs["header"]
+	if "" == header {
+		return errors.New("missing/empty -header name")
+	}
+	cert, key := aArgs["cert"], aArgs["key"]
+	if ("" == cert) != ("" == key) {
+		return errors.New("TLS needs both -cert and -key")
+	}
+	realm := aArgs["realm"]
+	if "" == realm {
+		realm = upstream.Host
+	}
+	reload, _ := time.ParseDuration(aArgs["reload"])
+
+	mw, err := ul.LoadMiddleware(
+		ul.WithRealm(realm),
+		ul.WithPasswdFile(aArgs["filename"]),
+		ul.WithDecider(ul.TAuthNeeder{}),
+		ul.WithReload(reload),
+		ul.WithURLUser(false),
+	)
+	if nil != err {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              aArgs["listen"],
+		Handler:           mw.Wrap(newProxy(upstream, header)),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	log.Printf("passproxy: forwarding %s to %s", server.Addr, upstream)
+	if "" != cert {
+		err = server.ListenAndServeTLS(cert, key)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+} // run()
+
+// `showHelp()` lists the commandline options to `Stderr`.
+func showHelp() {
+	fmt.Fprintf(os.Stderr, "\nUsage: %s [OPTIONS]\n\n", os.Args[0])
+	flag.CommandLine.PrintDefaults()
+	fmt.Fprint(os.Stderr, "\n")
+} // showHelp()
+
+// Application main routine …
+func main() {
+	args := getArguments()
+	if _, ok := args["upstream"]; !ok {
+		showHelp()
+		os.Exit(1)
+	}
+
+	if err := run(args); nil != err {
+		fmt.Fprintf(os.Stderr, "passproxy: %v\n", err)
+		os.Exit(1)
+	}
+} // main()
+
+/* _EoF_ */
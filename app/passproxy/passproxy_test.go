@@ -0,0 +1,86 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ul "github.com/mwat56/passlist"
+)
+
+func Test_newProxy(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		got = aRequest.Header.Clone()
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	tests := []struct {
+		name     string
+		header   string
+		user     string
+		wantUser string
+	}{
+		{" 1", "X-Remote-User", "username1", "username1"},
+		{" 2", "X-Remote-User", "", ""}, // client's value is removed
+		{" 3", "", "username1", ""},     // no identity header
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://proxy.example.com/page", nil)
+			req.SetBasicAuth("username1", "password1")
+			req.Header.Set("X-Remote-User", "admin")
+			if "" != tt.user {
+				req = req.WithContext(ul.ContextWithPrincipal(req.Context(),
+					&ul.TPrincipal{Name: tt.user, AuthMethod: ul.AuthBasic}))
+			}
+			rec := httptest.NewRecorder()
+			newProxy(target, tt.header).ServeHTTP(rec, req)
+
+			if http.StatusOK != rec.Code {
+				t.Fatalf("newProxy() status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if v := got.Get("Authorization"); "" != v {
+				t.Errorf("newProxy() passed Authorization = %q upstream", v)
+			}
+			if "" == tt.header {
+				if _, ok := got[""]; ok {
+					t.Error("newProxy() set an empty header name")
+				}
+				return
+			}
+			if v := got.Get(tt.header); v != tt.wantUser {
+				t.Errorf("newProxy() %s = %q, want %q", tt.header, v, tt.wantUser)
+			}
+		})
+	}
+} // Test_newProxy()
+
+func Test_run(t *testing.T) {
+	tests := []struct {
+		name string
+		args tArgumentList
+	}{
+		{" 1", tArgumentList{"header": "X-Remote-User"}},
+		{" 2", tArgumentList{"header": "X-Remote-User", "upstream": "/relative"}},
+		{" 3", tArgumentList{"upstream": "http://127.0.0.1:3000"}},
+		{" 4", tArgumentList{"header": "X-Remote-User", "upstream": "http://127.0.0.1:3000", "cert": "cert.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(tt.args); nil == err {
+				t.Errorf("run(%v) error = nil, want an error", tt.args)
+			}
+		})
+	}
+} // Test_run()
+
+/* _EoF_ */
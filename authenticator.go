@@ -0,0 +1,483 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides a common interface of the authentication schemes
+ * and a chain trying several of them in a configured order.
+ */
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+var (
+	// `ErrNoCredentials` is returned by an [IAuthenticator] if the
+	// request doesn't carry any credentials of its scheme.
+	ErrNoCredentials = errors.New("no credentials")
+)
+
+type (
+	// `IAuthenticator` is the interface of a single authentication
+	// scheme.
+	//
+	// An authenticator returns [ErrNoCredentials] if the request
+	// doesn't carry credentials of its scheme, thus allowing a
+	// [TAuthChain] to try the next one. Any other error means the
+	// request's credentials were rejected.
+	IAuthenticator interface {
+		// `Authenticate()` checks the credentials of `aRequest`
+		// returning the authenticated principal.
+		Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error)
+	}
+
+	// `IChallenger` is implemented by an [IAuthenticator] whose scheme
+	// is advertised in the `WWW-Authenticate` header of a denial.
+	IChallenger interface {
+		// `Challenges()` returns the scheme's challenges for `aRealm`.
+		Challenges(aRequest *http.Request, aRealm string, aErr error) []string
+	}
+
+	// `iRefresher` is implemented by an [IAuthenticator] that has
+	// to update the response of a successfully authenticated request
+	// (e.g. to resend a session cookie).
+	iRefresher interface {
+		refresh(aWriter http.ResponseWriter, aRequest *http.Request)
+	}
+
+	// `TAuthChain` tries several authentication schemes in the
+	// configured order.
+	//
+	// The first authenticator finding credentials of its scheme
+	// decides about the request; the remaining ones aren't asked.
+	TAuthChain struct {
+		authenticators []IAuthenticator
+	}
+
+	// `tBasicAuthenticator` adapts a user list to [IAuthenticator].
+	tBasicAuthenticator struct {
+		list IUserList
+	}
+
+	// `tCertAuthenticator` adapts a [TCertMap] to [IAuthenticator].
+	tCertAuthenticator struct {
+		certs *TCertMap
+		list  IUserList
+	}
+
+	// `tDigestAuthenticator` adapts a [TDigestAuth] to [IAuthenticator].
+	tDigestAuthenticator struct {
+		digest *TDigestAuth
+	}
+
+	// `tHeaderAuthenticator` adapts a [THeaderAuth] to [IAuthenticator].
+	tHeaderAuthenticator struct {
+		header *THeaderAuth
+	}
+
+	// `tKeyAuthenticator` adapts [TAPIKeys] to [IAuthenticator].
+	tKeyAuthenticator struct {
+		keys   *TAPIKeys
+		header string
+	}
+
+	// `tSessionAuthenticator` adapts [TSessions] to [IAuthenticator].
+	tSessionAuthenticator struct {
+		sessions *TSessions
+		list     IUserList
+	}
+
+	// `tTokenAuthenticator` adapts a [TJWT] to [IAuthenticator].
+	tTokenAuthenticator struct {
+		jwt *TJWT
+	}
+)
+
+// `NewAuthChain()` returns a chain trying `aAuthenticators` in the
+// given order.
+//
+// `nil` authenticators are skipped.
+//
+// Parameters:
+//   - `aAuthenticators`: The authentication schemes to try.
+//
+// Returns:
+//   - `*TAuthChain`: The new chain.
+func NewAuthChain(aAuthenticators ...IAuthenticator) *TAuthChain {
+	ac := &TAuthChain{
+		authenticators: make([]IAuthenticator, 0, len(aAuthenticators)),
+	}
+	for _, auth := range aAuthenticators {
+		if nil != auth {
+			ac.authenticators = append(ac.authenticators, auth)
+		}
+	}
+
+	return ac
+} // NewAuthChain()
+
+// `NewBasicAuthenticator()` returns an authenticator checking Basic
+// credentials against `aList`.
+//
+// Parameters:
+//   - `aList`: The user list to use.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewBasicAuthenticator(aList IUserList) IAuthenticator {
+	return &tBasicAuthenticator{list: aList}
+} // NewBasicAuthenticator()
+
+// `NewCertAuthenticator()` returns an authenticator mapping TLS client
+// certificates to the users of `aList` (see [WithCertMap]).
+//
+// Parameters:
+//   - `aMap`: The certificate mapping to use.
+//   - `aList`: The user list the mapped users must exist in.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewCertAuthenticator(aMap *TCertMap, aList IUserList) IAuthenticator {
+	return &tCertAuthenticator{certs: aMap, list: aList}
+} // NewCertAuthenticator()
+
+// `NewDigestAuthenticator()` returns an authenticator checking Digest
+// credentials (see [WithDigest]).
+//
+// Parameters:
+//   - `aDigest`: The Digest authentication to use.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewDigestAuthenticator(aDigest *TDigestAuth) IAuthenticator {
+	return &tDigestAuthenticator{digest: aDigest}
+} // NewDigestAuthenticator()
+
+// `NewHeaderAuthenticator()` returns an authenticator accepting the
+// identity header of a trusted proxy (see [WithHeaderAuth]).
+//
+// NOTE: The Basic fallback of `aAuth` isn't used by the authenticator;
+// add a [NewBasicAuthenticator] to the chain instead.
+//
+// Parameters:
+//   - `aAuth`: The identity header authentication to use.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewHeaderAuthenticator(aAuth *THeaderAuth) IAuthenticator {
+	return &tHeaderAuthenticator{header: aAuth}
+} // NewHeaderAuthenticator()
+
+// `NewKeyAuthenticator()` returns an authenticator checking API keys
+// (see [WithAPIKeys]).
+//
+// Parameters:
+//   - `aKeys`: The list of valid API keys.
+//   - `aHeader`: The name of an additional header carrying API keys (may be empty).
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewKeyAuthenticator(aKeys *TAPIKeys, aHeader string) IAuthenticator {
+	return &tKeyAuthenticator{keys: aKeys, header: aHeader}
+} // NewKeyAuthenticator()
+
+// `NewSessionAuthenticator()` returns an authenticator checking the
+// session cookies of `aSessions` (see [WithSessions]).
+//
+// An invalid session cookie isn't treated as a failure: the request
+// is passed on to the next authenticator of the chain.
+//
+// Parameters:
+//   - `aSessions`: The session manager to use.
+//   - `aList`: The user list the sessions' users must exist in.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewSessionAuthenticator(aSessions *TSessions, aList IUserList) IAuthenticator {
+	return &tSessionAuthenticator{sessions: aSessions, list: aList}
+} // NewSessionAuthenticator()
+
+// `NewTokenAuthenticator()` returns an authenticator checking JSON
+// Web Tokens (see [WithJWT]).
+//
+// Parameters:
+//   - `aJWT`: The token configuration to use.
+//
+// Returns:
+//   - `IAuthenticator`: The new authenticator.
+func NewTokenAuthenticator(aJWT *TJWT) IAuthenticator {
+	return &tTokenAuthenticator{jwt: aJWT}
+} // NewTokenAuthenticator()
+
+// --------------------------------------------------------------------------
+// `TAuthChain` methods:
+
+// `Authenticate()` checks the credentials of `aRequest` by the chain's
+// authenticators.
+//
+// Parameters:
+//   - `aCtx`: The context controlling a password verification.
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `*TPrincipal`: The authenticated principal.
+//   - `error`: [ErrNoCredentials] if no authenticator found credentials, or the first authenticator's error.
+func (ac *TAuthChain) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	p, _, err := ac.authenticate(aCtx, aRequest)
+
+	return p, err
+} // Authenticate()
+
+// `authenticate()` checks the credentials of `aRequest` by the chain's
+// authenticators.
+//
+// Parameters:
+//   - `aCtx`: The context controlling a password verification.
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `*TPrincipal`: The authenticated principal.
+//   - `IAuthenticator`: The authenticator that decided about the request.
+//   - `error`: A possible error during processing the request.
+func (ac *TAuthChain) authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, IAuthenticator, error) {
+	if nil == aRequest {
+		return nil, nil, se.New(errors.New("missing `aRequest`"), 2)
+	}
+	if nil == aCtx {
+		aCtx = aRequest.Context()
+	}
+
+	for _, auth := range ac.authenticators {
+		p, err := auth.Authenticate(aCtx, aRequest)
+		if nil == err {
+			return p, auth, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return nil, auth, err
+		}
+	}
+
+	return nil, nil, ErrNoCredentials
+} // authenticate()
+
+// `Challenges()` returns the challenges of all the chain's schemes
+// (without duplicates) in the configured order.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aRealm`: The name of the protected domain.
+//   - `aErr`: The reason of the denial.
+//
+// Returns:
+//   - `[]string`: The challenges to send in `WWW-Authenticate` headers.
+func (ac *TAuthChain) Challenges(aRequest *http.Request, aRealm string, aErr error) []string {
+	var result []string
+	for _, auth := range ac.authenticators {
+		if challenger, ok := auth.(IChallenger); ok {
+			for _, challenge := range challenger.Challenges(aRequest, aRealm, aErr) {
+				if !slices.Contains(result, challenge) {
+					result = append(result, challenge)
+				}
+			}
+		}
+	}
+
+	return result
+} // Challenges()
+
+// `IsAuthenticated()` checks the credentials of `aRequest` by the
+// chain's authenticators, returning `nil` for successful
+// authentication, or an `error` otherwise.
+//
+// On success the username is stored in the `aRequest.URL.User`
+// structure like [TPassList.IsAuthenticated] does.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ac *TAuthChain) IsAuthenticated(aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+	p, _, err := ac.authenticate(aRequest.Context(), aRequest)
+	if nil != err {
+		return err
+	}
+	aRequest.URL.User = url.User(p.Name)
+
+	return nil
+} // IsAuthenticated()
+
+// --------------------------------------------------------------------------
+// Adapter methods:
+
+// `Authenticate()` checks the Basic credentials of `aRequest`.
+func (ba *tBasicAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	user, _, ok := parseBasic(aRequest.Header.Get("Authorization"))
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	if nil == ba.list {
+		return nil, ErrInvalidCredentials
+	}
+
+	urlUser := aRequest.URL.User
+	err := ba.list.AuthenticateContext(aCtx, aRequest)
+	aRequest.URL.User = urlUser
+	if nil != err {
+		return nil, err
+	}
+
+	return newPrincipal(user, AuthBasic), nil
+} // Authenticate()
+
+// `Challenges()` returns the Basic challenge.
+func (ba *tBasicAuthenticator) Challenges(aRequest *http.Request, aRealm string, aErr error) []string {
+	return []string{basicChallenge(aRealm)}
+} // Challenges()
+
+// `Authenticate()` checks the client certificate of `aRequest`.
+func (ca *tCertAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	user, err := ca.certs.User(aRequest)
+	if nil != err {
+		return nil, err
+	}
+	if "" == user {
+		return nil, ErrNoCredentials
+	}
+	if (nil == ca.list) || !ca.list.Exists(user) {
+		return nil, ErrUnmappedCert
+	}
+
+	return certPrincipal(user, aRequest.TLS.PeerCertificates[0]), nil
+} // Authenticate()
+
+// `Authenticate()` checks the Digest credentials of `aRequest`.
+func (da *tDigestAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	if (nil == da.digest) || !isDigest(aRequest.Header.Get("Authorization")) {
+		return nil, ErrNoCredentials
+	}
+	user, err := da.digest.Authenticate(aRequest)
+	if nil != err {
+		return nil, err
+	}
+
+	return newPrincipal(user, AuthDigest), nil
+} // Authenticate()
+
+// `Challenges()` returns the Digest challenges.
+func (da *tDigestAuthenticator) Challenges(aRequest *http.Request, aRealm string, aErr error) []string {
+	return da.digest.Challenges(errors.Is(aErr, ErrStaleNonce))
+} // Challenges()
+
+// `Authenticate()` checks the identity header of `aRequest`.
+func (ha *tHeaderAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	user, err := ha.header.User(aRequest)
+	if nil != err {
+		return nil, err
+	}
+	if "" == user {
+		return nil, ErrNoCredentials
+	}
+
+	return newPrincipal(user, AuthHeader), nil
+} // Authenticate()
+
+// `Authenticate()` checks the API key sent with `aRequest`.
+func (ka *tKeyAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	token, ok := apiKeyFromRequest(aRequest, "Authorization", ka.header)
+	if !ok || (nil == ka.keys) {
+		return nil, ErrNoCredentials
+	}
+	key, err := ka.keys.Verify(token)
+	if nil != err {
+		return nil, err
+	}
+
+	return keyPrincipal(key), nil
+} // Authenticate()
+
+// `Challenges()` returns the Bearer challenge.
+func (ka *tKeyAuthenticator) Challenges(aRequest *http.Request, aRealm string, aErr error) []string {
+	_, sent := bearerToken(aRequest, "Authorization")
+
+	return []string{bearerChallenge(aRealm, sent)}
+} // Challenges()
+
+// `Authenticate()` checks the session cookie of `aRequest`.
+func (sa *tSessionAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	if (nil == sa.sessions) || (nil == sa.list) {
+		return nil, ErrNoCredentials
+	}
+	sess, err := sa.sessions.check(aRequest, sa.list.Find)
+	if nil != err {
+		return nil, ErrNoCredentials
+	}
+
+	return newPrincipal(sess.user, AuthSession), nil
+} // Authenticate()
+
+// `refresh()` resends the session cookie of `aRequest` if needed.
+func (sa *tSessionAuthenticator) refresh(aWriter http.ResponseWriter, aRequest *http.Request) {
+	if sess, err := sa.sessions.check(aRequest, sa.list.Find); nil == err {
+		sa.sessions.refresh(aWriter, sess)
+	}
+} // refresh()
+
+// `Authenticate()` checks the JSON Web Token sent with `aRequest`.
+func (ta *tTokenAuthenticator) Authenticate(aCtx context.Context, aRequest *http.Request) (*TPrincipal, error) {
+	token, ok := bearerToken(aRequest, "Authorization")
+	if !ok || !isJWT(token) || (nil == ta.jwt) {
+		return nil, ErrNoCredentials
+	}
+	claims, err := ta.jwt.Validate(token)
+	if nil != err {
+		return nil, err
+	}
+
+	return tokenPrincipal(claims), nil
+} // Authenticate()
+
+// `Challenges()` returns the Bearer challenge.
+func (ta *tTokenAuthenticator) Challenges(aRequest *http.Request, aRealm string, aErr error) []string {
+	_, sent := bearerToken(aRequest, "Authorization")
+
+	return []string{bearerChallenge(aRealm, sent)}
+} // Challenges()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `newPrincipal()` returns a new principal for the authenticated
+// `aUser` without roles.
+//
+// Parameters:
+//   - `aUser`: The authenticated user's name.
+//   - `aMethod`: The authentication scheme used.
+//
+// Returns:
+//   - `*TPrincipal`: The new principal.
+func newPrincipal(aUser, aMethod string) *TPrincipal {
+	return &TPrincipal{
+		Name:       aUser,
+		AuthMethod: aMethod,
+		AuthTime:   time.Now(),
+	}
+} // newPrincipal()
+
+/* _EoF_ */
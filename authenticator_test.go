@@ -0,0 +1,212 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_TAuthChain_Authenticate(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	c1 := testCert(t, "service1", "", "")
+	c2 := testCert(t, "service2", "", "")
+	cm := NewCertMap(filepath.Join(t.TempDir(), "certs.db"))
+	_ = cm.Add(u1, CertCN, "service1")
+
+	keys := NewAPIKeys(filepath.Join(t.TempDir(), "keys.db"))
+	key, _, _ := keys.Generate(u1, []string{"read"}, time.Time{})
+	jwt := NewJWT("issuer", "audience", time.Minute)
+	_ = jwt.AddHS256Key("k1", []byte(strings.Repeat("s", 32)))
+	token, _, _ := jwt.Issue(u1, nil, nil)
+
+	chain := NewAuthChain(
+		NewCertAuthenticator(cm, ul),
+		NewKeyAuthenticator(keys, "X-API-Key"),
+		NewTokenAuthenticator(jwt),
+		nil,
+		NewBasicAuthenticator(ul),
+	)
+
+	tests := []struct {
+		name       string
+		prepare    func(*http.Request)
+		wantMethod string
+		wantErr    error
+	}{
+		{" 1", func(r *http.Request) { r.SetBasicAuth(u1, p1) }, AuthBasic, nil},
+		{" 2", func(r *http.Request) { r.SetBasicAuth(u1, "wrong") }, "", ErrInvalidCredentials},
+		{" 3", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+key) }, AuthAPIKey, nil},
+		{" 4", func(r *http.Request) { r.Header.Set("X-API-Key", key) }, AuthAPIKey, nil},
+		{" 5", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }, AuthJWT, nil},
+		{" 6", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token+"x") }, "", ErrInvalidCredentials},
+		{" 7", func(r *http.Request) { *r = *tlsRequest(c1, true) }, AuthCert, nil},
+		{" 8", func(r *http.Request) {
+			*r = *tlsRequest(c2, true)
+			r.SetBasicAuth(u1, p1)
+		}, "", ErrUnmappedCert},
+		{" 9", func(r *http.Request) {
+			*r = *tlsRequest(nil, false)
+			r.SetBasicAuth(u1, p1)
+		}, AuthBasic, nil},
+		{"10", func(r *http.Request) {}, "", ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			tt.prepare(req)
+			got, err := chain.Authenticate(req.Context(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TAuthChain.Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if nil != tt.wantErr {
+				return
+			}
+			if (nil == got) || (u1 != got.Name) || (tt.wantMethod != got.AuthMethod) {
+				t.Errorf("TAuthChain.Authenticate() = %+v, want %q/%q", got, u1, tt.wantMethod)
+			}
+		})
+	}
+} // Test_TAuthChain_Authenticate()
+
+func Test_TAuthChain_Challenges(t *testing.T) {
+	ul := prepDB()
+	jwt := NewJWT("issuer", "audience", time.Minute)
+	keys := NewAPIKeys(filepath.Join(t.TempDir(), "keys.db"))
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+
+	tests := []struct {
+		name  string
+		chain *TAuthChain
+		want  []string
+	}{
+		{" 1", NewAuthChain(NewBasicAuthenticator(ul)),
+			[]string{basicChallenge("test")}},
+		{" 2", NewAuthChain(NewTokenAuthenticator(jwt), NewKeyAuthenticator(keys, ""), NewBasicAuthenticator(ul)),
+			[]string{bearerChallenge("test", false), basicChallenge("test")}},
+		{" 3", NewAuthChain(NewBasicAuthenticator(ul), NewSessionAuthenticator(NewSessions(0, 0), ul), NewTokenAuthenticator(jwt)),
+			[]string{basicChallenge("test"), bearerChallenge("test", false)}},
+		{" 4", NewAuthChain(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.chain.Challenges(req, "test", ErrNoCredentials)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("TAuthChain.Challenges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+} // Test_TAuthChain_Challenges()
+
+func Test_TMiddleware_chain(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	jwt := NewJWT("issuer", "audience", time.Minute)
+	_ = jwt.AddHS256Key("k1", []byte(strings.Repeat("s", 32)))
+	token, _, _ := jwt.Issue(u1, nil, nil)
+	sessions := NewSessions(0, 0)
+
+	var principal *TPrincipal
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithRealm("test"),
+		WithSessions(sessions),
+		WithRoles(func(aUser string) []string { return []string{"staff"} }),
+		WithChain(NewAuthChain(
+			NewSessionAuthenticator(sessions, ul),
+			NewTokenAuthenticator(jwt),
+			NewBasicAuthenticator(ul),
+		)),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+		aWriter.WriteHeader(http.StatusOK)
+	}))
+
+	// a successful Basic authentication issues a session cookie:
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.SetBasicAuth(u1, p1)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	cookies := rec.Result().Cookies()
+	if (http.StatusOK != rec.Code) || (1 != len(cookies)) {
+		t.Fatalf("TMiddleware.Wrap() status = %d, cookies = %d, want %d/1",
+			rec.Code, len(cookies), http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		prepare    func(*http.Request)
+		want       int
+		wantMethod string
+	}{
+		{" 1", func(r *http.Request) { r.AddCookie(cookies[0]) }, http.StatusOK, AuthSession},
+		{" 2", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }, http.StatusOK, AuthJWT},
+		{" 3", func(r *http.Request) { r.SetBasicAuth(u1, p1) }, http.StatusOK, AuthBasic},
+		{" 4", func(r *http.Request) { r.SetBasicAuth(u1, "wrong") }, http.StatusUnauthorized, ""},
+		{" 5", func(r *http.Request) {}, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			tt.prepare(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, tt.want)
+			}
+			if http.StatusOK != tt.want {
+				challenges := rec.Header().Values("WWW-Authenticate")
+				want := []string{`Bearer realm="test"`, `Basic realm="test", charset="UTF-8"`}
+				if !slices.Equal(challenges, want) {
+					t.Errorf("TMiddleware.Wrap() challenges = %q, want %q", challenges, want)
+				}
+				return
+			}
+			if (nil == principal) || (u1 != principal.Name) ||
+				(tt.wantMethod != principal.AuthMethod) || !principal.HasRole("staff") {
+				t.Errorf("TMiddleware.Wrap() principal = %+v, want %q/%q",
+					principal, u1, tt.wantMethod)
+			}
+		})
+	}
+} // Test_TMiddleware_chain()
+
+func Test_TMiddleware_chainProxy(t *testing.T) {
+	ul := prepDB().add0("username1", xxHash("password1"))
+	var logged strings.Builder
+	options := []TOption{
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithProxy(true),
+		WithChain(NewAuthChain(NewBasicAuthenticator(ul))),
+		WithLogger(log.New(&logged, "", 0)),
+	}
+
+	if mw := NewMiddleware(options...); nil != mw.chain {
+		t.Error("NewMiddleware() kept the chain in proxy mode")
+	}
+	if !strings.Contains(logged.String(), "proxy mode") {
+		t.Errorf("NewMiddleware() logged %q, want a warning", logged.String())
+	}
+	if _, err := LoadMiddleware(options...); nil == err {
+		t.Error("LoadMiddleware() accepted a chain in proxy mode")
+	}
+} // Test_TMiddleware_chainProxy()
+
+/* _EoF_ */
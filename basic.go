@@ -0,0 +1,168 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the RFC 7617 compliant handling of the
+ * `Basic` authentication scheme.
+ */
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+// `BasicAuth()` returns the username and password provided in the
+// `Authorization` header of `aRequest`.
+//
+// Other than `http.Request.BasicAuth()` the credentials are always
+// returned as UTF-8 (as announced by the `charset="UTF-8"` parameter
+// of our challenges): if the decoded data aren't valid UTF-8 they
+// are assumed to be ISO-8859-1 encoded (as sent by some older
+// clients) and converted accordingly.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `string`: The provided username.
+//   - `string`: The provided password.
+//   - `bool`: `true` if the request carried Basic credentials.
+func BasicAuth(aRequest *http.Request) (rUser, rPassword string, rOK bool) {
+	if nil == aRequest {
+		return
+	}
+
+	return parseBasic(aRequest.Header.Get("Authorization"))
+} // BasicAuth()
+
+// `ProxyAuth()` returns the username and password provided in the
+// `Proxy-Authorization` header of `aRequest`.
+//
+// See [BasicAuth] for details.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a proxy.
+//
+// Returns:
+//   - `string`: The provided username.
+//   - `string`: The provided password.
+//   - `bool`: `true` if the request carried Basic credentials.
+func ProxyAuth(aRequest *http.Request) (rUser, rPassword string, rOK bool) {
+	if nil == aRequest {
+		return
+	}
+
+	return parseBasic(aRequest.Header.Get("Proxy-Authorization"))
+} // ProxyAuth()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `basicChallenge()` returns the value of a `WWW-Authenticate`
+// header asking for Basic authentication in `aRealm`.
+//
+// Parameters:
+//   - `aRealm`: The symbolic name of the host/domain to protect.
+//
+// Returns:
+//   - `string`: The challenge to send.
+func basicChallenge(aRealm string) string {
+	return `Basic realm=` + quoteParam(aRealm) + `, charset="UTF-8"`
+} // basicChallenge()
+
+// `latin1ToUTF8()` converts the ISO-8859-1 encoded `aData` to UTF-8.
+//
+// Parameters:
+//   - `aData`: The text to convert.
+//
+// Returns:
+//   - `string`: The converted text.
+func latin1ToUTF8(aData []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(aData) * 2)
+	for _, b := range aData {
+		sb.WriteRune(rune(b))
+	}
+
+	return sb.String()
+} // latin1ToUTF8()
+
+// `parseBasic()` returns the credentials given by the value of an
+// `Authorization` (or `Proxy-Authorization`) header.
+//
+// Parameters:
+//   - `aHeader`: The header value to parse.
+//
+// Returns:
+//   - `string`: The provided username.
+//   - `string`: The provided password.
+//   - `bool`: `true` if the header carried Basic credentials.
+func parseBasic(aHeader string) (rUser, rPassword string, rOK bool) {
+	const prefix = "Basic "
+
+	// The scheme name is case-insensitive (RFC 7235, 2.1):
+	if (len(aHeader) < len(prefix)) || !strings.EqualFold(aHeader[:len(prefix)], prefix) {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(aHeader[len(prefix):]))
+	if nil != err {
+		return
+	}
+
+	var text string
+	if utf8.Valid(data) {
+		text = string(data)
+	} else {
+		text = latin1ToUTF8(data)
+	}
+
+	// The username must not contain a colon (RFC 7617, 2):
+	user, pass, ok := strings.Cut(text, ":")
+	if !ok {
+		return
+	}
+
+	return user, pass, true
+} // parseBasic()
+
+// `quoteParam()` returns `aValue` as a quoted string suitable for
+// an authentication parameter (RFC 7230, 3.2.6).
+//
+// Quotes and backslashes are escaped while control characters
+// (which can't be sent in a header) are removed.
+//
+// Parameters:
+//   - `aValue`: The text to quote.
+//
+// Returns:
+//   - `string`: The quoted text.
+func quoteParam(aValue string) string {
+	var sb strings.Builder
+	sb.Grow(len(aValue) + 2)
+	sb.WriteByte('"')
+	for _, r := range aValue {
+		switch {
+		case ('"' == r) || ('\\' == r):
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case (' ' > r) || (0x7f == r):
+			// skip control characters
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+
+	return sb.String()
+} // quoteParam()
+
+/* _EoF_ */
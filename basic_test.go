@@ -0,0 +1,103 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_BasicAuth(t *testing.T) {
+	b64 := func(aText string) string {
+		return base64.StdEncoding.EncodeToString([]byte(aText))
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		wantUser string
+		wantPass string
+		wantOK   bool
+	}{
+		{" 1", "", "", "", false},
+		{" 2", "Basic " + b64("user:pass"), "user", "pass", true},
+		{" 3", "basic " + b64("user:pa:ss"), "user", "pa:ss", true},
+		{" 4", "Basic " + b64("Jürgen:pässwört"), "Jürgen", "pässwört", true},
+		{" 5", "Basic " + b64("J\xfcrgen:p\xe4ssw\xf6rt"), "Jürgen", "pässwört", true},
+		{" 6", "Basic " + b64("nocolon"), "", "", false},
+		{" 7", "Basic !!invalid!!", "", "", false},
+		{" 8", "Bearer " + b64("user:pass"), "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			if "" != tt.header {
+				req.Header.Set("Authorization", tt.header)
+			}
+			user, pass, ok := BasicAuth(req)
+			if (user != tt.wantUser) || (pass != tt.wantPass) || (ok != tt.wantOK) {
+				t.Errorf("BasicAuth() = %q, %q, %v, want %q, %q, %v",
+					user, pass, ok, tt.wantUser, tt.wantPass, tt.wantOK)
+			}
+		})
+	}
+} // Test_BasicAuth()
+
+func Test_Deny(t *testing.T) {
+	tests := []struct {
+		name  string
+		realm string
+		want  string
+	}{
+		{" 1", "", `Basic realm="Default", charset="UTF-8"`},
+		{" 2", "My Site", `Basic realm="My Site", charset="UTF-8"`},
+		{" 3", `say "hi"`, `Basic realm="say \"hi\"", charset="UTF-8"`},
+		{" 4", `C:\temp`, `Basic realm="C:\\temp", charset="UTF-8"`},
+		{" 5", "evil\r\nX-Injected: 1", `Basic realm="evilX-Injected: 1", charset="UTF-8"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Deny(tt.realm, rec)
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("Deny() header = %q, want %q", got, tt.want)
+			}
+		})
+	}
+} // Test_Deny()
+
+func Test_DenyProxy(t *testing.T) {
+	rec := httptest.NewRecorder()
+	DenyProxy(`my "proxy"`, rec)
+
+	if http.StatusProxyAuthRequired != rec.Code {
+		t.Errorf("DenyProxy() status = %d, want %d",
+			rec.Code, http.StatusProxyAuthRequired)
+	}
+	want := `Basic realm="my \"proxy\"", charset="UTF-8"`
+	if got := rec.Header().Get("Proxy-Authenticate"); got != want {
+		t.Errorf("DenyProxy() header = %q, want %q", got, want)
+	}
+} // Test_DenyProxy()
+
+func Test_ProxyAuth(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.SetBasicAuth("user", "pass")
+	if _, _, ok := ProxyAuth(req); ok {
+		t.Error("ProxyAuth() accepted the Authorization header")
+	}
+
+	req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+	if user, pass, ok := ProxyAuth(req); ("user" != user) || ("pass" != pass) || !ok {
+		t.Errorf("ProxyAuth() = %q, %q, %v, want %q, %q, %v",
+			user, pass, ok, "user", "pass", true)
+	}
+} // Test_ProxyAuth()
+
+/* _EoF_ */
@@ -0,0 +1,203 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides a cache of successfully verified credentials.
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+type (
+	// `tCacheKey` is the keyed hash of a user/password pair.
+	tCacheKey [sha256.Size]byte
+
+	// `tCacheEntry` is a single verified credential.
+	tCacheEntry struct {
+		pwHash  string    // stored hash the credentials were verified with
+		expires time.Time // end of the entry's lifetime
+	}
+
+	// `TAuthCache` remembers successfully verified credentials for
+	// a limited time to avoid running a full `bcrypt` compare for
+	// each and every request.
+	//
+	// Browsers resend the Basic credentials with each request, so
+	// loading a single page with a dozen assets would otherwise
+	// require a dozen password verifications.
+	//
+	// The cache never stores plaintext passwords: the entries are
+	// indexed by an HMAC of the user/password pair using a random
+	// key created along with the cache.
+	TAuthCache struct {
+		mtx     sync.Mutex                // protect concurrent access
+		entries map[tCacheKey]tCacheEntry // list of verified credentials
+		key     []byte                    // HMAC key used for the entries
+		size    int                       // max. number of entries
+		ttl     time.Duration             // lifetime of an entry
+	}
+)
+
+// `NewAuthCache()` returns a new cache holding at most `aSize`
+// entries for at most `aTTL` each.
+//
+// If either `aSize` or `aTTL` is not positive the function
+// returns `nil` (i.e. no caching).
+//
+// Parameters:
+//   - `aSize`: The max. number of entries to hold.
+//   - `aTTL`: The max. lifetime of each entry.
+//
+// Returns:
+//   - `*TAuthCache`: The new cache.
+func NewAuthCache(aSize int, aTTL time.Duration) *TAuthCache {
+	if (0 >= aSize) || (0 >= aTTL) {
+		return nil
+	}
+
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); nil != err {
+		return nil
+	}
+
+	return &TAuthCache{
+		entries: make(map[tCacheKey]tCacheEntry, aSize),
+		key:     key,
+		size:    aSize,
+		ttl:     aTTL,
+	}
+} // NewAuthCache()
+
+// --------------------------------------------------------------------------
+// `TAuthCache` methods:
+
+// `Clear()` removes all entries from the cache.
+func (ac *TAuthCache) Clear() {
+	if nil == ac {
+		return
+	}
+
+	ac.mtx.Lock()
+	clear(ac.entries)
+	ac.mtx.Unlock()
+} // Clear()
+
+// `digest()` returns the keyed hash of the given user/password pair.
+//
+// Parameters:
+//   - `aUser`: The username.
+//   - `aPassword`: The (unhashed) password.
+//
+// Returns:
+//   - `tCacheKey`: The HMAC of the given credentials.
+func (ac *TAuthCache) digest(aUser, aPassword string) (rKey tCacheKey) {
+	mac := hmac.New(sha256.New, ac.key)
+	mac.Write([]byte(aUser))
+	mac.Write([]byte{0})
+	mac.Write([]byte(aPassword + pwPepper))
+	copy(rKey[:], mac.Sum(nil))
+
+	return
+} // digest()
+
+// `Len()` returns the current number of cache entries.
+//
+// Returns:
+//   - `int`: The number of cached credentials.
+func (ac *TAuthCache) Len() int {
+	if nil == ac {
+		return 0
+	}
+
+	ac.mtx.Lock()
+	defer ac.mtx.Unlock()
+
+	return len(ac.entries)
+} // Len()
+
+// `lookup()` returns whether the given credentials were verified
+// recently against the user's current `aPwHash`.
+//
+// Entries verified against another (i.e. older) password hash are
+// removed from the cache.
+//
+// Parameters:
+//   - `aUser`: The username.
+//   - `aPassword`: The (unhashed) password.
+//   - `aPwHash`: The user's currently stored password hash.
+//
+// Returns:
+//   - `bool`: `true` if the credentials are known to be valid.
+func (ac *TAuthCache) lookup(aUser, aPassword, aPwHash string) bool {
+	if nil == ac {
+		return false
+	}
+	key := ac.digest(aUser, aPassword)
+
+	ac.mtx.Lock()
+	defer ac.mtx.Unlock()
+
+	entry, ok := ac.entries[key]
+	if !ok {
+		return false
+	}
+	if (entry.pwHash != aPwHash) || time.Now().After(entry.expires) {
+		delete(ac.entries, key)
+		return false
+	}
+
+	return true
+} // lookup()
+
+// `store()` adds the given verified credentials to the cache.
+//
+// If the cache is full all expired entries are removed and if
+// that doesn't suffice an arbitrary entry is dropped.
+//
+// Parameters:
+//   - `aUser`: The username.
+//   - `aPassword`: The (unhashed) password.
+//   - `aPwHash`: The password hash the credentials were verified with.
+func (ac *TAuthCache) store(aUser, aPassword, aPwHash string) {
+	if nil == ac {
+		return
+	}
+	key := ac.digest(aUser, aPassword)
+	now := time.Now()
+
+	ac.mtx.Lock()
+	defer ac.mtx.Unlock()
+
+	if _, ok := ac.entries[key]; !ok && (len(ac.entries) >= ac.size) {
+		for k, entry := range ac.entries {
+			if now.After(entry.expires) {
+				delete(ac.entries, k)
+			}
+		}
+		for k := range ac.entries {
+			if len(ac.entries) < ac.size {
+				break
+			}
+			delete(ac.entries, k)
+		}
+	}
+
+	ac.entries[key] = tCacheEntry{
+		pwHash:  aPwHash,
+		expires: now.Add(ac.ttl),
+	}
+} // store()
+
+/* _EoF_ */
@@ -0,0 +1,116 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NewAuthCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		ttl     time.Duration
+		wantNil bool
+	}{
+		{" 1", 8, time.Minute, false},
+		{" 2", 0, time.Minute, true},
+		{" 3", 8, 0, true},
+		{" 4", -1, -time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAuthCache(tt.size, tt.ttl); (nil == got) != tt.wantNil {
+				t.Errorf("NewAuthCache() = %v, wantNil %v",
+					got, tt.wantNil)
+			}
+		})
+	}
+} // Test_NewAuthCache()
+
+func Test_TAuthCache_lookup(t *testing.T) {
+	u1, p1, h1 := "username1", "password1", "hash1"
+	ac := NewAuthCache(2, time.Minute)
+	ac.store(u1, p1, h1)
+
+	tests := []struct {
+		name string
+		user string
+		pass string
+		hash string
+		want bool
+	}{
+		{" 1", u1, p1, h1, true},
+		{" 2", u1, "password2", h1, false}, // wrong password
+		{" 3", "username2", p1, h1, false}, // wrong user
+		{" 4", u1, p1, "hash2", false},     // changed hash
+		{" 5", u1, p1, h1, false},          // removed by 4
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ac.lookup(tt.user, tt.pass, tt.hash); got != tt.want {
+				t.Errorf("TAuthCache.lookup() = %v, want %v",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_TAuthCache_lookup()
+
+func Test_TAuthCache_store(t *testing.T) {
+	ac := NewAuthCache(2, time.Minute)
+	ac.store("u1", "p1", "h1")
+	ac.store("u2", "p2", "h2")
+	ac.store("u3", "p3", "h3")
+
+	if got := ac.Len(); 2 != got {
+		t.Errorf("TAuthCache.Len() = %d, want %d", got, 2)
+	}
+	if !ac.lookup("u3", "p3", "h3") {
+		t.Errorf("TAuthCache.lookup() latest entry missing")
+	}
+
+	ac.Clear()
+	if got := ac.Len(); 0 != got {
+		t.Errorf("TAuthCache.Len() = %d, want %d", got, 0)
+	}
+
+	ac = NewAuthCache(2, time.Nanosecond)
+	ac.store("u1", "p1", "h1")
+	time.Sleep(time.Millisecond)
+	if ac.lookup("u1", "p1", "h1") {
+		t.Errorf("TAuthCache.lookup() returned expired entry")
+	}
+} // Test_TAuthCache_store()
+
+func Test_TPassList_SetCache(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1)).SetCache(NewAuthCache(8, time.Minute))
+
+	if !ul.Matches(u1, p1) {
+		t.Fatalf("TPassList.Matches() = false, want true")
+	}
+	if got := ul.cache.Len(); 1 != got {
+		t.Errorf("TAuthCache.Len() = %d, want %d", got, 1)
+	}
+	if ul.Matches(u1, "wrongpassword") {
+		t.Errorf("TPassList.Matches() = true, want false")
+	}
+
+	// changing the password must invalidate the cached entry
+	ul.add0(u1, xxHash("password2"))
+	if ul.Matches(u1, p1) {
+		t.Errorf("TPassList.Matches() accepted outdated password")
+	}
+
+	ul.Clear()
+	if got := ul.cache.Len(); 0 != got {
+		t.Errorf("TAuthCache.Len() = %d, want %d", got, 0)
+	}
+} // Test_TPassList_SetCache()
+
+/* _EoF_ */
@@ -0,0 +1,426 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the mapping of TLS client certificates
+ * to the users of a user list.
+ */
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `CertCN` maps a certificate's subject common name.
+	CertCN = "cn"
+
+	// `CertEmail` maps an email address of a certificate's SAN.
+	CertEmail = "email"
+
+	// `CertSPKI` maps a certificate's public key fingerprint
+	// (see [CertFingerprint]).
+	CertSPKI = "spki"
+
+	// `CertURI` maps a URI of a certificate's SAN (e.g. a SPIFFE ID).
+	CertURI = "uri"
+)
+
+var (
+	// `ErrUnmappedCert` is passed to [THooks.OnFailure] if a request
+	// was refused because its client certificate isn't mapped to
+	// a (known) user.
+	ErrUnmappedCert = errors.New("client certificate not mapped to a user")
+)
+
+type (
+	// `tCertKey` identifies a certificate property.
+	tCertKey struct {
+		kind  string // one of [CertCN], [CertEmail], [CertSPKI], [CertURI]
+		value string // the property's (normalised) value
+	}
+
+	// `TCertMap` maps TLS client certificates to users.
+	//
+	// A certificate is identified by its public key fingerprint,
+	// the URIs or email addresses of its subject alternative names,
+	// or its subject's common name (checked in that order). The
+	// fingerprint pins a single key and is accepted even if the
+	// server doesn't verify the certificate chain; all other
+	// properties are only trusted for verified certificates.
+	//
+	// The file format is one mapping per line with the fields
+	// "user:kind:value" separated by colons (the value may contain
+	// further colons); empty lines and comments (starting with `#`
+	// or `;`) are skipped.
+	TCertMap struct {
+		mtx      sync.RWMutex        // protect concurrent access
+		filename string              // name of the mapping file
+		entries  map[tCertKey]string // users by certificate property
+	}
+)
+
+// `LoadCertMap()` returns a new `TCertMap` instance with the
+// contents of `aFilename`.
+//
+// Parameters:
+//   - `aFilename`: Name of the mapping file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TCertMap`: A new `TCertMap` instance.
+//   - `error`: A possible error during processing the request.
+func LoadCertMap(aFilename string) (*TCertMap, error) {
+	cm := NewCertMap(aFilename)
+	if nil == cm {
+		return nil, se.New(errors.New(`missing/empty file name`), 2)
+	}
+
+	return cm, cm.Load()
+} // LoadCertMap()
+
+// `NewCertMap()` returns a new `TCertMap` instance.
+//
+// If `aFilename` is empty the function returns `nil`.
+//
+// Parameters:
+//   - `aFilename`: Name of the mapping file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TCertMap`: A new `TCertMap` instance.
+func NewCertMap(aFilename string) *TCertMap {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		return nil
+	}
+
+	return &TCertMap{
+		filename: aFilename,
+		entries:  make(map[tCertKey]string, 16),
+	}
+} // NewCertMap()
+
+// --------------------------------------------------------------------------
+// `TCertMap` methods:
+
+// `Add()` maps the certificate property `aKind` with `aValue`
+// to `aUser`.
+//
+// Parameters:
+//   - `aUser`: The user's name.
+//   - `aKind`: One of [CertCN], [CertEmail], [CertSPKI], or [CertURI].
+//   - `aValue`: The property's value.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (cm *TCertMap) Add(aUser, aKind, aValue string) error {
+	if err := checkDigestName(aUser, "username"); nil != err {
+		return se.New(err, 1)
+	}
+	key, err := certKey(aKind, aValue)
+	if nil != err {
+		return se.New(err, 1)
+	}
+
+	cm.mtx.Lock()
+	cm.entries[key] = aUser
+	cm.mtx.Unlock()
+
+	return nil
+} // Add()
+
+// `Clear()` empties the mapping.
+//
+// Returns:
+//   - `*TCertMap`: The cleared mapping.
+func (cm *TCertMap) Clear() *TCertMap {
+	cm.mtx.Lock()
+	clear(cm.entries)
+	cm.mtx.Unlock()
+
+	return cm
+} // Clear()
+
+// `Len()` returns the number of mappings.
+//
+// Returns:
+//   - `int`: The number of mappings.
+func (cm *TCertMap) Len() int {
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+
+	return len(cm.entries)
+} // Len()
+
+// `List()` returns a sorted list of all mappings as "user:kind:value".
+//
+// Returns:
+//   - `[]string`: The list of mappings.
+func (cm *TCertMap) List() []string {
+	cm.mtx.RLock()
+	result := make([]string, 0, len(cm.entries))
+	for key, user := range cm.entries {
+		result = append(result, user+":"+key.kind+":"+key.value)
+	}
+	cm.mtx.RUnlock()
+	slices.Sort(result)
+
+	return result
+} // List()
+
+// `Load()` reads the mapping file replacing the current contents.
+//
+// A malformed line results in an error naming the file and line;
+// the current contents then remain unchanged.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (cm *TCertMap) Load() error {
+	if "" == cm.filename {
+		return se.New(errors.New("missing/empty filename"), 1)
+	}
+
+	file, err := os.Open(cm.filename)
+	if nil != err {
+		return se.New(err, 2)
+	}
+	defer file.Close()
+
+	entries := make(map[tCertKey]string, 16)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if (0 == len(line)) || (';' == line[0]) || ('#' == line[0]) {
+			// Skip blank and comment lines
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 3)
+		if 3 > len(parts) {
+			return se.New(fmt.Errorf("%s:%d: invalid mapping '%s'", cm.filename, lineNo, line), 1)
+		}
+		user := strings.TrimSpace(parts[0])
+		if "" == user {
+			return se.New(fmt.Errorf("%s:%d: missing/empty username", cm.filename, lineNo), 1)
+		}
+		key, err := certKey(parts[1], parts[2])
+		if nil != err {
+			return se.New(fmt.Errorf("%s:%d: %w", cm.filename, lineNo, err), 1)
+		}
+		entries[key] = user
+	}
+	if err = scanner.Err(); nil != err {
+		return se.New(err, 1)
+	}
+
+	cm.mtx.Lock()
+	cm.entries = entries
+	cm.mtx.Unlock()
+
+	return nil
+} // Load()
+
+// `Lookup()` returns the user `aCert` is mapped to.
+//
+// Parameters:
+//   - `aCert`: The client certificate to lookup.
+//   - `aVerified`: Whether the certificate's chain was verified.
+//
+// Returns:
+//   - `string`: The user's name.
+//   - `bool`: `true` if the certificate is mapped, or `false` otherwise.
+func (cm *TCertMap) Lookup(aCert *x509.Certificate, aVerified bool) (string, bool) {
+	if (nil == cm) || (nil == aCert) {
+		return "", false
+	}
+	keys := []tCertKey{{CertSPKI, CertFingerprint(aCert)}}
+	if aVerified {
+		for _, uri := range aCert.URIs {
+			keys = append(keys, tCertKey{CertURI, uri.String()})
+		}
+		for _, email := range aCert.EmailAddresses {
+			keys = append(keys, tCertKey{CertEmail, strings.ToLower(email)})
+		}
+		if "" != aCert.Subject.CommonName {
+			keys = append(keys, tCertKey{CertCN, aCert.Subject.CommonName})
+		}
+	}
+
+	cm.mtx.RLock()
+	defer cm.mtx.RUnlock()
+
+	for _, key := range keys {
+		if user, ok := cm.entries[key]; ok {
+			return user, true
+		}
+	}
+
+	return "", false
+} // Lookup()
+
+// `Remove()` deletes the mapping of property `aKind` with `aValue`.
+//
+// Parameters:
+//   - `aKind`: One of [CertCN], [CertEmail], [CertSPKI], or [CertURI].
+//   - `aValue`: The property's value.
+//
+// Returns:
+//   - `*TCertMap`: The updated mapping.
+func (cm *TCertMap) Remove(aKind, aValue string) *TCertMap {
+	if key, err := certKey(aKind, aValue); nil == err {
+		cm.mtx.Lock()
+		delete(cm.entries, key)
+		cm.mtx.Unlock()
+	}
+
+	return cm
+} // Remove()
+
+// `Store()` writes the mapping to the mapping file.
+//
+// Returns:
+//   - `int`: The number of bytes written.
+//   - `error`: A possible error during processing the request.
+func (cm *TCertMap) Store() (int, error) {
+	if "" == cm.filename {
+		return 0, se.New(errors.New("missing/empty filename"), 1)
+	}
+	s := []byte(cm.String())
+
+	file, err := os.OpenFile(cm.filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G302
+	if nil != err {
+		return 0, se.New(err, 2)
+	}
+	defer file.Close()
+
+	return file.Write(s)
+} // Store()
+
+// `String()` returns the mapping as a single, LF-separated string.
+//
+// Returns:
+//   - `string`: A stringified representation of the mapping.
+func (cm *TCertMap) String() string {
+	list := cm.List()
+	if 0 == len(list) {
+		return ""
+	}
+
+	return strings.Join(list, "\n") + "\n"
+} // String()
+
+// `User()` returns the user the verified client certificate of
+// `aRequest` is mapped to.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `string`: The user's name (empty if there's no client certificate).
+//   - `error`: [ErrUnmappedCert] if the certificate isn't mapped, or `nil`.
+func (cm *TCertMap) User(aRequest *http.Request) (string, error) {
+	if (nil == aRequest) || (nil == aRequest.TLS) || (0 == len(aRequest.TLS.PeerCertificates)) {
+		return "", nil
+	}
+	user, ok := cm.Lookup(aRequest.TLS.PeerCertificates[0], 0 < len(aRequest.TLS.VerifiedChains))
+	if !ok {
+		return "", ErrUnmappedCert
+	}
+
+	return user, nil
+} // User()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `CertFingerprint()` returns the hex encoded SHA-256 hash of the
+// public key (i.e. the "SubjectPublicKeyInfo") of `aCert`.
+//
+// Since the fingerprint covers the key only it remains the same if
+// the certificate is renewed with the same key.
+//
+// Parameters:
+//   - `aCert`: The certificate to use.
+//
+// Returns:
+//   - `string`: The certificate's SPKI fingerprint.
+func CertFingerprint(aCert *x509.Certificate) string {
+	if nil == aCert {
+		return ""
+	}
+	sum := sha256.Sum256(aCert.RawSubjectPublicKeyInfo)
+
+	return hex.EncodeToString(sum[:])
+} // CertFingerprint()
+
+// `certKey()` returns the normalised mapping key of `aKind` and `aValue`.
+//
+// Parameters:
+//   - `aKind`: One of [CertCN], [CertEmail], [CertSPKI], or [CertURI].
+//   - `aValue`: The property's value.
+//
+// Returns:
+//   - `tCertKey`: The mapping key.
+//   - `error`: A possible error during processing the request.
+func certKey(aKind, aValue string) (tCertKey, error) {
+	kind := strings.ToLower(strings.TrimSpace(aKind))
+	value := strings.TrimSpace(aValue)
+	if "" == value {
+		return tCertKey{}, fmt.Errorf("missing/empty %s value", kind)
+	}
+
+	switch kind {
+	case CertCN, CertURI:
+	case CertEmail:
+		value = strings.ToLower(value)
+	case CertSPKI:
+		value = strings.ToLower(strings.ReplaceAll(value, ":", ""))
+		if b, err := hex.DecodeString(value); (nil != err) || (sha256.Size != len(b)) {
+			return tCertKey{}, fmt.Errorf("invalid SPKI fingerprint '%s'", aValue)
+		}
+	default:
+		return tCertKey{}, fmt.Errorf("unknown certificate property '%s'", aKind)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return tCertKey{}, fmt.Errorf("%s '%s' contains invalid characters", kind, value)
+	}
+
+	return tCertKey{kind, value}, nil
+} // certKey()
+
+// `certPrincipal()` returns a new principal for `aUser` authenticated
+// by the client certificate `aCert`.
+//
+// Parameters:
+//   - `aUser`: The user the certificate is mapped to.
+//   - `aCert`: The client certificate.
+//
+// Returns:
+//   - `*TPrincipal`: The new principal.
+func certPrincipal(aUser string, aCert *x509.Certificate) *TPrincipal {
+	p := newPrincipal(aUser, AuthCert)
+	p.Attributes = map[string]string{
+		"subject": aCert.Subject.String(),
+		"spki":    CertFingerprint(aCert),
+	}
+
+	return p
+} // certPrincipal()
+
+/* _EoF_ */
@@ -0,0 +1,224 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert is an internal test helper returning a self-signed
+// client certificate.
+func testCert(t *testing.T, aCN, aEmail, aURI string) *x509.Certificate {
+	t.Helper()
+	public, private, _ := ed25519.GenerateKey(nil)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: aCN},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if "" != aEmail {
+		tmpl.EmailAddresses = []string{aEmail}
+	}
+	if "" != aURI {
+		u, _ := url.Parse(aURI)
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(nil, tmpl, tmpl, public, private)
+	if nil != err {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return cert
+} // testCert()
+
+// tlsRequest is an internal test helper returning a request
+// carrying the client certificate `aCert`.
+func tlsRequest(aCert *x509.Certificate, aVerified bool) *http.Request {
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	if nil != aCert {
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{aCert}}
+		if aVerified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{aCert}}
+		}
+	}
+
+	return req
+} // tlsRequest()
+
+func Test_TCertMap_User(t *testing.T) {
+	c1 := testCert(t, "service1", "", "")
+	c2 := testCert(t, "other", "Ops@Example.com", "")
+	c3 := testCert(t, "other", "", "spiffe://example.com/svc3")
+	c4 := testCert(t, "pinned", "", "")
+	c5 := testCert(t, "unknown", "", "")
+
+	cm := NewCertMap(filepath.Join(t.TempDir(), "certs.db"))
+	_ = cm.Add("username1", CertCN, "service1")
+	_ = cm.Add("username2", CertEmail, "ops@example.com")
+	_ = cm.Add("username3", "URI", "spiffe://example.com/svc3")
+	_ = cm.Add("username4", CertSPKI, CertFingerprint(c4))
+	if err := cm.Add("username5", "serial", "1"); nil == err {
+		t.Error("TCertMap.Add() expected error for unknown kind")
+	}
+	if err := cm.Add("username5", CertSPKI, "abcd"); nil == err {
+		t.Error("TCertMap.Add() expected error for invalid fingerprint")
+	}
+
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		verified bool
+		want     string
+		wantErr  error
+	}{
+		{" 1", c1, true, "username1", nil},
+		{" 2", c2, true, "username2", nil},
+		{" 3", c3, true, "username3", nil},
+		{" 4", c4, true, "username4", nil},
+		{" 5", c4, false, "username4", nil},
+		{" 6", c1, false, "", ErrUnmappedCert},
+		{" 7", c5, true, "", ErrUnmappedCert},
+		{" 8", nil, false, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cm.User(tlsRequest(tt.cert, tt.verified))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TCertMap.User() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("TCertMap.User() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// round trip through the mapping file:
+	if _, err := cm.Store(); nil != err {
+		t.Fatalf("TCertMap.Store() error = %v", err)
+	}
+	loaded, err := LoadCertMap(cm.filename)
+	if (nil != err) || (loaded.String() != cm.String()) || (4 != loaded.Len()) {
+		t.Errorf("LoadCertMap() = %q, %v, want %q", loaded.String(), err, cm.String())
+	}
+	if loaded.Remove(CertEmail, "OPS@example.com"); 3 != loaded.Len() {
+		t.Errorf("TCertMap.Remove() Len() = %d, want %d", loaded.Len(), 3)
+	}
+} // Test_TCertMap_User()
+
+func Test_TCertMap_Load(t *testing.T) {
+	dir := t.TempDir()
+	spki := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name     string
+		contents string
+		wantLine string
+	}{
+		{" 1", "# comment\nalice:cn:Alice\nbob:spki:" + spki + "\n", ""},
+		{" 2", "alice:cn:Alice\nbob:spki:" + spki[:62] + "\n", ":2:"},
+		{" 3", "alice:cn:Alice\n\nbob:nickname:Bob\n", ":3:"},
+		{" 4", "alice:Alice\n", ":1:"},
+		{" 5", ":cn:Alice\n", ":1:"},
+		{" 6", "alice:email:\n", ":1:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(dir, "certs"+strings.TrimSpace(tt.name)+".db")
+			if err := os.WriteFile(fn, []byte(tt.contents), 0600); nil != err {
+				t.Fatal(err)
+			}
+			cm := NewCertMap(fn)
+			err := cm.Load()
+			if "" == tt.wantLine {
+				if (nil != err) || (2 != cm.Len()) {
+					t.Errorf("TCertMap.Load() = %d, %v, want %d, nil", cm.Len(), err, 2)
+				}
+				return
+			}
+			if (nil == err) || !strings.Contains(err.Error(), fn+tt.wantLine) {
+				t.Errorf("TCertMap.Load() error = %v, want %q", err, fn+tt.wantLine)
+			}
+		})
+	}
+} // Test_TCertMap_Load()
+
+func Test_TMiddleware_certMap(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	c1 := testCert(t, "service1", "", "")
+	c2 := testCert(t, "service2", "", "")
+	c3 := testCert(t, "service3", "", "")
+
+	cm := NewCertMap(filepath.Join(t.TempDir(), "certs.db"))
+	_ = cm.Add(u1, CertCN, "service1")
+	_ = cm.Add("username2", CertCN, "service2") // not in the user list
+
+	var principal *TPrincipal
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithCertMap(cm),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+		aWriter.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		cert       *x509.Certificate
+		basic      bool
+		want       int
+		wantMethod string
+	}{
+		{" 1", c1, false, http.StatusOK, AuthCert},
+		{" 2", c2, false, http.StatusForbidden, ""},
+		{" 3", c3, true, http.StatusForbidden, ""},
+		{" 4", nil, true, http.StatusOK, AuthBasic},
+		{" 5", nil, false, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := tlsRequest(tt.cert, true)
+			if tt.basic {
+				req.SetBasicAuth(u1, p1)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, tt.want)
+			}
+			if http.StatusOK != tt.want {
+				return
+			}
+			if (nil == principal) || (u1 != principal.Name) || (tt.wantMethod != principal.AuthMethod) {
+				t.Errorf("TMiddleware.Wrap() principal = %+v, want %q/%q",
+					principal, u1, tt.wantMethod)
+			}
+		})
+	}
+} // Test_TMiddleware_certMap()
+
+/* _EoF_ */
@@ -0,0 +1,304 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the detection of a request's client address
+ * honouring a list of trusted reverse proxies.
+ */
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+type (
+	// `TTrustedProxies` is a list of networks whose hosts are trusted
+	// to report a client's address in the `X-Forwarded-For` (or the
+	// `Forwarded`) header.
+	TTrustedProxies struct {
+		prefixes  []netip.Prefix // the trusted networks
+		forwarded bool           // use the RFC 7239 `Forwarded` header
+	}
+)
+
+// `NewTrustedProxies()` returns a new list of trusted proxies.
+//
+// Each of `aCIDRs` is either a network in CIDR notation (like
+// `10.0.0.0/8` or `fd00::/8`) or a single IPv4/IPv6 address.
+//
+// Parameters:
+//   - `aCIDRs`: The networks/addresses to trust.
+//
+// Returns:
+//   - `*TTrustedProxies`: The new list.
+//   - `error`: A possible error during processing the request.
+func NewTrustedProxies(aCIDRs ...string) (*TTrustedProxies, error) {
+	prefixes, err := parsePrefixes(aCIDRs)
+	if nil != err {
+		return nil, err // already wrapped
+	}
+
+	return &TTrustedProxies{prefixes: prefixes}, nil
+} // NewTrustedProxies()
+
+// --------------------------------------------------------------------------
+// `TTrustedProxies` methods:
+
+// `ClientIP()` returns the address of the client sending `aRequest`.
+//
+// If the request's remote address is a trusted proxy the
+// `X-Forwarded-For` header (or the `Forwarded` header, see
+// [TTrustedProxies.SetForwarded]) is searched from right to left
+// for the first address that's not a trusted proxy.
+//
+// A `nil` list trusts no proxy at all and always returns the
+// request's remote address.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `netip.Addr`: The client's address (invalid if unknown).
+func (tp *TTrustedProxies) ClientIP(aRequest *http.Request) netip.Addr {
+	if nil == aRequest {
+		return netip.Addr{}
+	}
+	remote := remoteAddr(aRequest)
+	if !tp.Contains(remote) {
+		return remote
+	}
+
+	var hops []netip.Addr
+	if tp.forwarded {
+		hops = forwardedHeader(aRequest.Header.Values("Forwarded"))
+	} else {
+		hops = forwardedFor(aRequest.Header.Values("X-Forwarded-For"))
+	}
+	for idx := len(hops) - 1; 0 <= idx; idx-- {
+		if !tp.Contains(hops[idx]) {
+			return hops[idx]
+		}
+		remote = hops[idx]
+	}
+
+	return remote
+} // ClientIP()
+
+// `Contains()` returns whether `aAddr` is a trusted proxy.
+//
+// Parameters:
+//   - `aAddr`: The address to check.
+//
+// Returns:
+//   - `bool`: `true` if the address is trusted, or `false` otherwise.
+func (tp *TTrustedProxies) Contains(aAddr netip.Addr) bool {
+	if (nil == tp) || !aAddr.IsValid() {
+		return false
+	}
+
+	return containsAddr(tp.prefixes, aAddr)
+} // Contains()
+
+// `SetForwarded()` decides whether the client's address is taken
+// from the standard `Forwarded` header (RFC 7239) instead of the
+// `X-Forwarded-For` header.
+//
+// Enable it only if all your proxies set the `Forwarded` header;
+// otherwise a client could send a forged one.
+//
+// Parameters:
+//   - `aEnable`: Whether to use the `Forwarded` header.
+//
+// Returns:
+//   - `*TTrustedProxies`: The list itself, allowing method chaining.
+func (tp *TTrustedProxies) SetForwarded(aEnable bool) *TTrustedProxies {
+	tp.forwarded = aEnable
+
+	return tp
+} // SetForwarded()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `containsAddr()` returns whether `aAddr` is part of `aPrefixes`.
+//
+// Parameters:
+//   - `aPrefixes`: The networks to check.
+//   - `aAddr`: The address to look for.
+//
+// Returns:
+//   - `bool`: `true` if the address was found, or `false` otherwise.
+func containsAddr(aPrefixes []netip.Prefix, aAddr netip.Addr) bool {
+	aAddr = aAddr.Unmap()
+	for _, prefix := range aPrefixes {
+		if prefix.Contains(aAddr) {
+			return true
+		}
+	}
+
+	return false
+} // containsAddr()
+
+// `forwardedFor()` returns the addresses listed in the given
+// `X-Forwarded-For` header values.
+//
+// Entries that are no valid addresses are returned as invalid
+// addresses (which are never trusted).
+//
+// Parameters:
+//   - `aValues`: The header values to parse.
+//
+// Returns:
+//   - `[]netip.Addr`: The listed addresses.
+func forwardedFor(aValues []string) []netip.Addr {
+	var result []netip.Addr
+	for _, value := range aValues {
+		for _, hop := range strings.Split(value, ",") {
+			result = append(result, parseAddr(hop))
+		}
+	}
+
+	return result
+} // forwardedFor()
+
+// `forwardedHeader()` returns the addresses given by the `for`
+// parameters of the `Forwarded` header values `aValues` (RFC 7239).
+//
+// Each forwarded element yields one entry; elements without a `for`
+// parameter, or with an obfuscated identifier (like "unknown" or
+// "_hidden") are returned as invalid addresses (which are never
+// trusted).
+//
+// Parameters:
+//   - `aValues`: The header values to parse.
+//
+// Returns:
+//   - `[]netip.Addr`: The listed addresses.
+func forwardedHeader(aValues []string) []netip.Addr {
+	var result []netip.Addr
+	for _, value := range aValues {
+		var (
+			addr          netip.Addr
+			param         strings.Builder
+			quoted, found bool
+		)
+		// `flush()` evaluates the current parameter.
+		flush := func() {
+			name, val, ok := strings.Cut(param.String(), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "for") && !found {
+				addr, found = parseAddr(val), true
+			}
+			param.Reset()
+		}
+
+		for idx := 0; idx < len(value); idx++ {
+			char := value[idx]
+			switch {
+			case quoted && ('\\' == char) && (idx+1 < len(value)):
+				idx++
+				param.WriteByte(value[idx])
+			case '"' == char:
+				quoted = !quoted
+			case quoted:
+				param.WriteByte(char)
+			case ';' == char:
+				flush()
+			case ',' == char:
+				flush()
+				result = append(result, addr)
+				addr, found = netip.Addr{}, false
+			default:
+				param.WriteByte(char)
+			}
+		}
+		flush()
+		result = append(result, addr)
+	}
+
+	return result
+} // forwardedHeader()
+
+// `parseAddr()` returns the address given by `aHost` which may
+// include a port number and/or brackets.
+//
+// Parameters:
+//   - `aHost`: The text to parse.
+//
+// Returns:
+//   - `netip.Addr`: The address (invalid if `aHost` isn't one).
+func parseAddr(aHost string) netip.Addr {
+	aHost = strings.TrimSpace(aHost)
+	if host, _, err := net.SplitHostPort(aHost); nil == err {
+		aHost = host
+	}
+	aHost = strings.TrimSuffix(strings.TrimPrefix(aHost, "["), "]")
+
+	addr, err := netip.ParseAddr(aHost)
+	if nil != err {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap()
+} // parseAddr()
+
+// `parsePrefixes()` returns the networks given by `aCIDRs`.
+//
+// Parameters:
+//   - `aCIDRs`: The networks (or single addresses) to parse.
+//
+// Returns:
+//   - `[]netip.Prefix`: The parsed networks.
+//   - `error`: A possible error during processing the request.
+func parsePrefixes(aCIDRs []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(aCIDRs))
+	for _, cidr := range aCIDRs {
+		if cidr = strings.TrimSpace(cidr); "" == cidr {
+			continue
+		}
+		if strings.Contains(cidr, "/") {
+			prefix, err := netip.ParsePrefix(cidr)
+			if nil != err {
+				return nil, se.New(fmt.Errorf("invalid network %q: %w", cidr, err), 1)
+			}
+			if prefix.Addr().Is4In6() && (96 <= prefix.Bits()) {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			}
+			result = append(result, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(cidr)
+		if nil != err {
+			return nil, se.New(fmt.Errorf("invalid address %q: %w", cidr, err), 1)
+		}
+		addr = addr.Unmap()
+		result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return result, nil
+} // parsePrefixes()
+
+// `remoteAddr()` returns the remote address of `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `netip.Addr`: The remote address (invalid if unknown).
+func remoteAddr(aRequest *http.Request) netip.Addr {
+	return parseAddr(aRequest.RemoteAddr)
+} // remoteAddr()
+
+/* _EoF_ */
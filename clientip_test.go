@@ -0,0 +1,115 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_NewTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		wantErr bool
+	}{
+		{" 1", nil, false},
+		{" 2", []string{"10.0.0.0/8", "::1", " 192.168.1.1 "}, false},
+		{" 3", []string{"10.0.0.0/33"}, true},
+		{" 4", []string{"not.an.address"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTrustedProxies(tt.cidrs...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTrustedProxies() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+		})
+	}
+} // Test_NewTrustedProxies()
+
+func Test_TTrustedProxies_ClientIP(t *testing.T) {
+	tp, err := NewTrustedProxies("10.0.0.0/8", "::1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		proxies *TTrustedProxies
+		remote  string
+		xff     string
+		want    string
+	}{
+		{" 1", nil, "192.0.2.1:1234", "198.51.100.7", "192.0.2.1"},
+		{" 2", tp, "192.0.2.1:1234", "198.51.100.7", "192.0.2.1"},
+		{" 3", tp, "10.1.2.3:1234", "198.51.100.7", "198.51.100.7"},
+		{" 4", tp, "10.1.2.3:1234", "198.51.100.7, 10.9.9.9", "198.51.100.7"},
+		{" 5", tp, "10.1.2.3:1234", "203.0.113.5, 198.51.100.7", "198.51.100.7"},
+		{" 6", tp, "[::1]:1234", "10.2.2.2", "10.2.2.2"},
+		{" 7", tp, "10.1.2.3:1234", "", "10.1.2.3"},
+		{" 8", tp, "10.1.2.3:1234", "garbage", "invalid IP"},
+		{" 9", tp, "[::ffff:192.0.2.9]:1234", "", "192.0.2.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remote
+			if "" != tt.xff {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := tt.proxies.ClientIP(req).String(); got != tt.want {
+				t.Errorf("TTrustedProxies.ClientIP() = %q, want %q",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_TTrustedProxies_ClientIP()
+
+func Test_TTrustedProxies_forwarded(t *testing.T) {
+	tp, err := NewTrustedProxies("10.0.0.0/8", "2001:db8::/32")
+	if nil != err {
+		t.Fatal(err)
+	}
+	tp.SetForwarded(true)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		xff       string
+		want      string
+	}{
+		{" 1", "10.1.2.3:1234", "for=198.51.100.7", "", "198.51.100.7"},
+		{" 2", "10.1.2.3:1234", `for="[2001:db8:cafe::17]:4711", for=198.51.100.7;proto=https`, "", "198.51.100.7"},
+		{" 3", "10.1.2.3:1234", `For="[2001:db7::17]:4711";by=10.0.0.1, for=10.9.9.9`, "", "2001:db7::17"},
+		{" 4", "10.1.2.3:1234", "for=unknown", "", "invalid IP"},
+		{" 5", "10.1.2.3:1234", `proto=http;host="a,b", for=198.51.100.7`, "", "198.51.100.7"},
+		{" 6", "10.1.2.3:1234", "", "198.51.100.7", "10.1.2.3"},
+		{" 7", "192.0.2.1:1234", "for=198.51.100.7", "", "192.0.2.1"},
+		{" 8", "10.1.2.3:1234", "by=10.0.0.1", "", "invalid IP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remote
+			if "" != tt.forwarded {
+				req.Header.Set("Forwarded", tt.forwarded)
+			}
+			if "" != tt.xff {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := tp.ClientIP(req).String(); got != tt.want {
+				t.Errorf("TTrustedProxies.ClientIP() = %q, want %q",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_TTrustedProxies_forwarded()
+
+/* _EoF_ */
@@ -12,10 +12,12 @@ package passlist
  */
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -30,6 +32,79 @@ var (
 
 // --------------------------------------------------------------------------
 
+// `AddAPIKey()` generates a new API key for `aOwner`, adds it to the
+// key file `aFilename` (see [TAPIKeys]), and prints it to `Stdout`.
+//
+// The key is printed only once and can't be recovered later on.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aOwner`: The name of the key's owner.
+//   - `aScopes`: The comma separated scopes to grant to the key.
+//   - `aTTL`: The key's lifetime (zero: never expiring).
+//   - `aFilename`: The name of the key file to use.
+func AddAPIKey(aOwner, aScopes string, aTTL time.Duration, aFilename string) {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "missing/empty file name\n")
+		}
+		os.Exit(1)
+	}
+
+	ak := NewAPIKeys(aFilename) // never `nil` since `aFilename` is not empty now
+	if err := ak.Load(); nil != err {
+		// Ignore the error only if the file doesn't exist yet
+		if _, serr := os.Stat(aFilename); !errors.Is(serr, os.ErrNotExist) {
+			if Verbose {
+				fmt.Fprintf(os.Stderr, "\n\tcan't use key file: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	}
+	storeAPIKey(ak, aOwner, strings.Split(aScopes, ","), aTTL, "added")
+} // AddAPIKey()
+
+// `AddDigestUser()` reads a password for `aUser` from the commandline
+// and adds its HA1 values for `aRealm` to the HA1 file `aFilename`
+// (see [TDigestStore]).
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aUser`: The username to add to the HA1 file.
+//   - `aRealm`: The protection space of the new entry.
+//   - `aFilename`: The name of the HA1 file to use.
+func AddDigestUser(aUser, aRealm, aFilename string) {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "missing/empty file name\n")
+		}
+		os.Exit(1)
+	}
+
+	ds := NewDigestStore(aFilename) // never `nil` since `aFilename` is not empty now
+	if err := ds.Load(); nil != err {
+		// Ignore the error if the file doesn't exist yet
+		if _, serr := os.Stat(aFilename); nil == serr {
+			if Verbose {
+				fmt.Fprintf(os.Stderr, "\n\tcan't use HA1 file: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	}
+	if ds.Exists(aUser, aRealm) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\t'%s' already exists in realm '%s'\n", aUser, aRealm)
+		}
+		os.Exit(1)
+	}
+
+	storeDigestUser(ds, aUser, aRealm, "added")
+} // AddDigestUser()
+
 // `AddUser()` reads a password for `aUser` from the commandline and adds
 // it to `aFilename`.
 //
@@ -85,6 +160,62 @@ func AddUser(aUser, aFilename string) {
 	os.Exit(0)
 } // AddUser()
 
+// `CheckAPIKey()` reads an API key from the commandline (at the
+// password prompt) and checks it against the entry `aPrefix` of the
+// key file `aFilename`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aPrefix`: The identifying prefix of the key to check.
+//   - `aFilename`: The name of the key file to use.
+func CheckAPIKey(aPrefix, aFilename string) {
+	ak := readAPIKey(aPrefix, aFilename)
+	key := readPassword(false)
+	exitCode, result := 0, "successful"
+
+	if k, err := ak.Verify(key); nil != err {
+		exitCode, result = 1, fmt.Sprintf("failed: %v", err)
+	} else if k.Prefix != aPrefix {
+		exitCode, result = 1, "failed: key '"+k.Prefix+"' given"
+	}
+
+	if Verbose {
+		fmt.Printf("\n\tAPI key '%s' check %s\n\n", aPrefix, result)
+	}
+
+	os.Exit(exitCode)
+} // CheckAPIKey()
+
+// `CheckDigestUser()` reads a password for `aUser` from the commandline
+// and compares it with the HA1 value of `aRealm` stored in `aFilename`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aUser`: The username to check with the HA1 file.
+//   - `aRealm`: The protection space of the entry.
+//   - `aFilename`: The name of the HA1 file to use.
+func CheckDigestUser(aUser, aRealm, aFilename string) {
+	ds := readDigestUser(aUser, aRealm, aFilename)
+	pw := readPassword(false)
+	exitCode := 0
+
+	if ds.Matches(aUser, aRealm, pw) {
+		pw = "successful"
+	} else {
+		exitCode, pw = 1, "failed"
+	}
+
+	if Verbose {
+		fmt.Printf("\n\t'%s' password check %s\n\n", aUser, pw)
+	}
+
+	os.Exit(exitCode)
+} // CheckDigestUser()
+
 // `CheckUser()` reads a password for `aUser` from the commandline and
 // compares it with the one stored in `aFilename`.
 //
@@ -112,6 +243,59 @@ func CheckUser(aUser, aFilename string) {
 	os.Exit(exitCode)
 } // CheckUser()
 
+// `DeleteAPIKey()` removes (i.e. revokes) the key `aPrefix` from the
+// key file `aFilename`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aPrefix`: The identifying prefix of the key to delete.
+//   - `aFilename`: The name of the key file to use.
+func DeleteAPIKey(aPrefix, aFilename string) {
+	ak := readAPIKey(aPrefix, aFilename)
+
+	if _, err := ak.Remove(aPrefix).Store(); nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't store modified list: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if Verbose {
+		fmt.Printf("\n\tremoved API key '%s'\n\n", aPrefix)
+	}
+
+	os.Exit(0)
+} // DeleteAPIKey()
+
+// `DeleteDigestUser()` removes the entry of `aUser` in `aRealm` from
+// the HA1 file `aFilename`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aUser`: The username to delete from the HA1 file.
+//   - `aRealm`: The protection space of the entry.
+//   - `aFilename`: The name of the HA1 file to use.
+func DeleteDigestUser(aUser, aRealm, aFilename string) {
+	ds := readDigestUser(aUser, aRealm, aFilename)
+
+	if _, err := ds.Remove(aUser, aRealm).Store(); nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't store modified list: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if Verbose {
+		fmt.Printf("\n\tremoved '%s' from realm '%s'\n\n", aUser, aRealm)
+	}
+
+	os.Exit(0)
+} // DeleteDigestUser()
+
 // `DeleteUser()` removes the entry for `aUser` from the password list
 // in `aFilename`.
 //
@@ -138,6 +322,54 @@ func DeleteUser(aUser, aFilename string) {
 	os.Exit(0)
 } // DeleteUser()
 
+// `ListAPIKeys()` reads the key file `aFilename` and lists all keys
+// (without their secrets) stored in there.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aFilename`: The name of the key file to use.
+func ListAPIKeys(aFilename string) {
+	ak := loadAPIKeys(aFilename)
+	keys := ak.List()
+	if 0 == len(keys) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "no API keys found in key file '%s'\n", aFilename)
+		}
+		os.Exit(1)
+	}
+	list := make([]string, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key.String())
+	}
+	fmt.Println(strings.Join(list, "\n") + "\n")
+
+	os.Exit(0)
+} // ListAPIKeys()
+
+// `ListDigestUsers()` reads the HA1 file `aFilename` and lists all
+// users (and their realms) stored in there.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aFilename`: The name of the HA1 file to use.
+func ListDigestUsers(aFilename string) {
+	ds := loadDigestStore(aFilename)
+	list := ds.List()
+	if 0 == len(list) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "no users found in HA1 file '%s'\n", aFilename)
+		}
+		os.Exit(1)
+	}
+	fmt.Println(strings.Join(list, "\n") + "\n")
+
+	os.Exit(0)
+} // ListDigestUsers()
+
 // `ListUsers()` reads `aFilename` and lists all users stored in there.
 //
 // NOTE: This function does not return but terminates the program with
@@ -159,6 +391,64 @@ func ListUsers(aFilename string) {
 	os.Exit(0)
 } // ListUsers()
 
+// `loadAPIKeys()` returns a new `TAPIKeys` instance.
+//
+// NOTE: This function terminates in case of errors and only returns
+// with a valid `TAPIKeys` instance.
+//
+// Parameters:
+//   - `aFilename`: The name of the key file to use.
+//
+// Returns:
+//   - `*TAPIKeys`: A new `TAPIKeys` instance
+func loadAPIKeys(aFilename string) *TAPIKeys {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "missing/empty file name\n")
+		}
+		os.Exit(1)
+	}
+
+	ak, err := LoadAPIKeys(aFilename)
+	if nil != err {
+		if Verbose {
+			fmt.Fprint(os.Stderr, "can't open key file »", aFilename, "«\n")
+		}
+		os.Exit(1)
+	}
+
+	return ak
+} // loadAPIKeys()
+
+// `loadDigestStore()` returns a new `TDigestStore` instance.
+//
+// NOTE: This function terminates in case of errors and only returns
+// with a valid `TDigestStore` instance.
+//
+// Parameters:
+//   - `aFilename`: The name of the HA1 file to use.
+//
+// Returns:
+//   - `*TDigestStore`: A new `TDigestStore` instance
+func loadDigestStore(aFilename string) *TDigestStore {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "missing/empty file name\n")
+		}
+		os.Exit(1)
+	}
+
+	ds, err := LoadDigestStore(aFilename)
+	if nil != err {
+		if Verbose {
+			fmt.Fprint(os.Stderr, "can't open HA1 file »", aFilename, "«\n")
+		}
+		os.Exit(1)
+	}
+
+	return ds
+} // loadDigestStore()
+
 // `loadList()` returns a new `TPassList` instance.
 //
 // NOTE: This function terminates in case of errors and only returns
@@ -188,6 +478,55 @@ func loadList(aFilename string) (ul *TPassList) {
 	return ul
 } // loadList()
 
+// `readAPIKey()` checks whether the key `aPrefix` exists in the key
+// file `aFilename` and returns the list if so.
+//
+// NOTE: If the key doesn't exist the function terminates the program
+// with error code `1` (one).
+//
+// Parameters:
+//   - `aPrefix`: The identifying prefix of the key.
+//   - `aFilename`: The name of the key file to use.
+//
+// Returns:
+//   - `*TAPIKeys`: The list of API keys.
+func readAPIKey(aPrefix, aFilename string) *TAPIKeys {
+	ak := loadAPIKeys(aFilename)
+	if !ak.Exists(aPrefix) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't find API key '%s'\n", aPrefix)
+		}
+		os.Exit(1)
+	}
+
+	return ak
+} // readAPIKey()
+
+// `readDigestUser()` checks whether `aUser` exists in `aRealm` of the
+// HA1 file `aFilename` and returns the store if so.
+//
+// NOTE: If `aUser` doesn't exist the function terminates the program
+// with error code `1` (one).
+//
+// Parameters:
+//   - `aUser`: The username to check with the HA1 file.
+//   - `aRealm`: The protection space of the entry.
+//   - `aFilename`: The name of the HA1 file to use.
+//
+// Returns:
+//   - `*TDigestStore`: The HA1 store.
+func readDigestUser(aUser, aRealm, aFilename string) *TDigestStore {
+	ds := loadDigestStore(aFilename)
+	if !ds.Exists(aUser, aRealm) {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't find '%s' in realm '%s'\n", aUser, aRealm)
+		}
+		os.Exit(1)
+	}
+
+	return ds
+} // readDigestUser()
+
 // `readPassword()` asks the user to input a password on the commandline.
 //
 // Parameters:
@@ -259,6 +598,114 @@ func readUser(aUser, aFilename string) *TPassList {
 	return ul
 } // readUser()
 
+// `storeAPIKey()` generates a new API key for `aOwner`, stores the
+// updated key file, and prints the key to `Stdout`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aKeys`: The list of API keys to update.
+//   - `aOwner`: The name of the key's owner.
+//   - `aScopes`: The scopes to grant to the key.
+//   - `aTTL`: The key's lifetime (zero: never expiring).
+//   - `aAction`: The action's name for the final message.
+func storeAPIKey(aKeys *TAPIKeys, aOwner string, aScopes []string, aTTL time.Duration, aAction string) {
+	var expires time.Time
+	if 0 < aTTL {
+		expires = time.Now().Add(aTTL)
+	}
+	key, info, err := aKeys.Generate(aOwner, aScopes, expires)
+	if nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't generate API key for '%s': %v\n", aOwner, err)
+		}
+		os.Exit(1)
+	}
+
+	if _, err = aKeys.Store(); nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't store modified list: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if Verbose {
+		fmt.Printf("\t%s API key %s\n\n", aAction, info.String())
+	}
+	// The key is printed even in quiet mode since it can't be
+	// recovered later on.
+	fmt.Println(key)
+
+	os.Exit(0)
+} // storeAPIKey()
+
+// `storeDigestUser()` reads a password for `aUser` from the commandline
+// and stores the updated HA1 file.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aStore`: The HA1 store to update.
+//   - `aUser`: The username to add/update.
+//   - `aRealm`: The protection space of the entry.
+//   - `aAction`: The action's name for the final message.
+func storeDigestUser(aStore *TDigestStore, aUser, aRealm, aAction string) {
+	pw := readPassword(true)
+	if err := aStore.Add(aUser, aRealm, pw); nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't store '%s': %v\n", aUser, err)
+		}
+		os.Exit(1)
+	}
+
+	if _, err := aStore.Store(); nil != err {
+		if Verbose {
+			fmt.Fprintf(os.Stderr, "\n\tcan't store modified list: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if Verbose {
+		fmt.Printf("\t%s '%s' in realm '%s'\n\n", aAction, aUser, aRealm)
+	}
+
+	os.Exit(0)
+} // storeDigestUser()
+
+// `UpdateAPIKey()` replaces the key `aPrefix` in the key file
+// `aFilename` by a new one with the same owner and scopes, and
+// prints the new key to `Stdout`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aPrefix`: The identifying prefix of the key to replace.
+//   - `aTTL`: The new key's lifetime (zero: never expiring).
+//   - `aFilename`: The name of the key file to use.
+func UpdateAPIKey(aPrefix string, aTTL time.Duration, aFilename string) {
+	ak := readAPIKey(aPrefix, aFilename)
+	old, _ := ak.Find(aPrefix) // exists as checked by `readAPIKey()`
+
+	storeAPIKey(ak.Remove(aPrefix), old.Owner, old.Scopes, aTTL, "replaced "+aPrefix+" by")
+} // UpdateAPIKey()
+
+// `UpdateDigestUser()` reads a password for `aUser` from the commandline
+// and updates its HA1 values for `aRealm` in the HA1 file `aFilename`.
+//
+// NOTE: This function does not return but terminates the program with
+// error code `0` (zero) if successful, or `1` (one) otherwise.
+//
+// Parameters:
+//   - `aUser`: The username to update in the HA1 file.
+//   - `aRealm`: The protection space of the entry.
+//   - `aFilename`: The name of the HA1 file to use.
+func UpdateDigestUser(aUser, aRealm, aFilename string) {
+	storeDigestUser(readDigestUser(aUser, aRealm, aFilename), aUser, aRealm, "updated")
+} // UpdateDigestUser()
+
 // `UpdateUser()` reads a password for `aUser` from the commandline and
 // updates the entry in the password list `aFilename`.
 //
@@ -0,0 +1,643 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides `IAuthDecider` implementations deciding by
+ * the request's path, method, or client address.
+ */
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// Kinds of path patterns:
+	ruleExact  = uint8(iota) // the path itself
+	rulePrefix               // the path and everything below
+	ruleGlob                 // a `path.Match` pattern
+	ruleMux                  // a `ServeMux` pattern with wildcards
+)
+
+type (
+	// `TMethodDecider` provides an [IAuthDecider] implementation
+	// granting public access for some HTTP methods only (e.g. for
+	// read-only public access).
+	//
+	// Requests using one of the public methods are decided by an
+	// optional path decider (or don't need authentication at all);
+	// requests using any other method always need authentication.
+	// A `HEAD` request is treated like a `GET` request. CORS preflight
+	// requests (see [IsPreflight]) never need authentication since
+	// browsers don't send credentials with them.
+	TMethodDecider struct {
+		methods map[string]bool // the public methods
+		paths   IAuthDecider    // optional decider for public methods
+	}
+
+	// `TNetworkDecider` provides an [IAuthDecider] implementation
+	// deciding by the client's address.
+	//
+	// Clients from a denied network always need authentication,
+	// clients from an allowed network never do (the deny list takes
+	// precedence); all other requests are decided by an optional
+	// fallback decider (or need authentication). Requests whose
+	// client address is unknown always need authentication.
+	//
+	// The client's address is determined by the list of trusted
+	// proxies (see [TTrustedProxies.ClientIP]); without trusted
+	// proxies the request's remote address is used.
+	TNetworkDecider struct {
+		mtx     sync.RWMutex     // protect concurrent access
+		allow   []netip.Prefix   // networks skipping authentication
+		deny    []netip.Prefix   // networks forced to authenticate
+		proxies *TTrustedProxies // proxies reporting the client's address
+		next    IAuthDecider     // optional decider for other clients
+	}
+
+	// `tPathRule` is a single rule of a [TPathDecider].
+	tPathRule struct {
+		method  string   // HTTP method (empty: any method)
+		pattern string   // the path pattern
+		segs    []string // the pattern's segments (`ruleMux` only)
+		kind    uint8    // the kind of pattern
+		need    bool     // whether matching requests need authentication
+	}
+
+	// `TPathDecider` provides an [IAuthDecider] implementation
+	// deciding by ordered include and exclude rules.
+	//
+	// The rules are checked in the order they were added; the first
+	// rule matching the request decides whether it needs
+	// authentication ("include") or not ("exclude"). If no rule
+	// matches, the default (see [TPathDecider.SetDefault]) is used.
+	//
+	// A pattern may start with an HTTP method followed by a space
+	// (e.g. "POST /api/"); a `GET` pattern matches `HEAD` requests
+	// as well. The path is one of
+	//   - an exact path like "/login",
+	//   - a path ending with a slash like "/static/" matching the
+	//     path itself and everything below it,
+	//   - a glob pattern like "/img/*.png" (see [path.Match]; a `*`
+	//     doesn't match a slash, but a trailing "/**" matches
+	//     everything below the directory), or
+	//   - a [http.ServeMux] pattern with wildcards like
+	//     "/users/{id}/posts", "/files/{path...}", or "/{$}".
+	//
+	// Host names aren't supported in patterns. The request's path is
+	// cleaned (like [http.ServeMux] does) before matching, so "/a/../b"
+	// is matched as "/b".
+	TPathDecider struct {
+		mtx   sync.RWMutex // protect concurrent access
+		rules []tPathRule  // the ordered rules
+		dflt  bool         // result if no rule matches
+	}
+)
+
+// `LoadPathDecider()` returns a new `TPathDecider` with the rules
+// read from `aFilename`.
+//
+// The file holds one rule per line: either "include <pattern>" or
+// "exclude <pattern>", or "default include|exclude" to set the
+// result for requests not matching any rule. Empty lines and
+// comments (starting with `#` or `;`) are skipped.
+//
+// Parameters:
+//   - `aFilename`: The name of the rules file.
+//
+// Returns:
+//   - `*TPathDecider`: A new `TPathDecider` instance.
+//   - `error`: A possible error during processing the request.
+func LoadPathDecider(aFilename string) (*TPathDecider, error) {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		return nil, se.New(errors.New(`missing/empty file name`), 1)
+	}
+
+	file, err := os.Open(aFilename)
+	if nil != err {
+		return nil, se.New(err, 2)
+	}
+	defer file.Close()
+
+	pd := NewPathDecider()
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if (0 == len(line)) || (';' == line[0]) || ('#' == line[0]) {
+			// Skip blank and comment lines
+			continue
+		}
+
+		action, pattern, _ := strings.Cut(line, " ")
+		pattern = strings.TrimSpace(pattern)
+		switch strings.ToLower(action) {
+		case "include":
+			err = pd.Include(pattern)
+		case "exclude":
+			err = pd.Exclude(pattern)
+		case "default":
+			switch strings.ToLower(pattern) {
+			case "include":
+				pd.SetDefault(true)
+			case "exclude":
+				pd.SetDefault(false)
+			default:
+				err = fmt.Errorf("invalid default '%s'", pattern)
+			}
+		default:
+			err = fmt.Errorf("unknown action '%s'", action)
+		}
+		if nil != err {
+			return nil, se.New(fmt.Errorf("%s:%d: %w", aFilename, lineNo, err), 1)
+		}
+	}
+	if err = scanner.Err(); nil != err {
+		return nil, se.New(err, 1)
+	}
+
+	return pd, nil
+} // LoadPathDecider()
+
+// `NewMethodDecider()` returns a new decider granting public access
+// for `aMethods`.
+//
+// If `aMethods` is empty the safe methods `GET`, `HEAD`, and `OPTIONS`
+// are public.
+//
+// Parameters:
+//   - `aPaths`: The decider for requests using a public method (may be `nil`).
+//   - `aMethods`: The public HTTP methods.
+//
+// Returns:
+//   - `*TMethodDecider`: A new `TMethodDecider` instance.
+func NewMethodDecider(aPaths IAuthDecider, aMethods ...string) *TMethodDecider {
+	if 0 == len(aMethods) {
+		aMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+	md := &TMethodDecider{
+		methods: make(map[string]bool, len(aMethods)+1),
+		paths:   aPaths,
+	}
+	for _, method := range aMethods {
+		if method = strings.ToUpper(strings.TrimSpace(method)); "" != method {
+			md.methods[method] = true
+		}
+	}
+	if md.methods[http.MethodGet] {
+		// `HEAD` must not reveal more than `GET` would.
+		md.methods[http.MethodHead] = true
+	}
+
+	return md
+} // NewMethodDecider()
+
+// `NewNetworkDecider()` returns a new decider without any networks.
+//
+// Parameters:
+//   - `aProxies`: The proxies trusted to report the client's address (may be `nil`).
+//   - `aNext`: The decider for clients from other networks (may be `nil`).
+//
+// Returns:
+//   - `*TNetworkDecider`: A new `TNetworkDecider` instance.
+func NewNetworkDecider(aProxies *TTrustedProxies, aNext IAuthDecider) *TNetworkDecider {
+	return &TNetworkDecider{
+		proxies: aProxies,
+		next:    aNext,
+	}
+} // NewNetworkDecider()
+
+// `NewPathDecider()` returns a new `TPathDecider` without any rules.
+//
+// By default requests not matching any rule need authentication.
+//
+// Returns:
+//   - `*TPathDecider`: A new `TPathDecider` instance.
+func NewPathDecider() *TPathDecider {
+	return &TPathDecider{
+		rules: make([]tPathRule, 0, 8),
+		dflt:  true,
+	}
+} // NewPathDecider()
+
+// --------------------------------------------------------------------------
+// `TMethodDecider` methods:
+
+// `NeedAuthentication()` returns whether `aRequest` needs
+// authentication according to its HTTP method.
+//
+// Parameters:
+//   - `aRequest`: holds the URL to check.
+//
+// Returns:
+//   - `bool`: `true` if authentication is required, or `false` otherwise.
+func (md *TMethodDecider) NeedAuthentication(aRequest *http.Request) bool {
+	if nil == aRequest {
+		return true
+	}
+	if IsPreflight(aRequest) {
+		return false
+	}
+
+	method := aRequest.Method
+	if "" == method {
+		method = http.MethodGet
+	}
+	if !md.methods[method] {
+		return true
+	}
+	if nil == md.paths {
+		return false
+	}
+
+	return md.paths.NeedAuthentication(aRequest)
+} // NeedAuthentication()
+
+// --------------------------------------------------------------------------
+// `TNetworkDecider` methods:
+
+// `Allow()` adds networks whose clients don't need authentication.
+//
+// Each of `aCIDRs` is either a network in CIDR notation (like
+// `10.0.0.0/8` or `fd00::/8`) or a single IPv4/IPv6 address.
+//
+// Parameters:
+//   - `aCIDRs`: The networks/addresses to allow.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (nd *TNetworkDecider) Allow(aCIDRs ...string) error {
+	prefixes, err := parsePrefixes(aCIDRs)
+	if nil != err {
+		return err // already wrapped
+	}
+
+	nd.mtx.Lock()
+	nd.allow = append(nd.allow, prefixes...)
+	nd.mtx.Unlock()
+
+	return nil
+} // Allow()
+
+// `Deny()` adds networks whose clients always need authentication.
+//
+// Each of `aCIDRs` is either a network in CIDR notation (like
+// `10.0.0.0/8` or `fd00::/8`) or a single IPv4/IPv6 address.
+//
+// Parameters:
+//   - `aCIDRs`: The networks/addresses to deny.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (nd *TNetworkDecider) Deny(aCIDRs ...string) error {
+	prefixes, err := parsePrefixes(aCIDRs)
+	if nil != err {
+		return err // already wrapped
+	}
+
+	nd.mtx.Lock()
+	nd.deny = append(nd.deny, prefixes...)
+	nd.mtx.Unlock()
+
+	return nil
+} // Deny()
+
+// `NeedAuthentication()` returns whether `aRequest` needs
+// authentication according to its client's address.
+//
+// Parameters:
+//   - `aRequest`: holds the URL to check.
+//
+// Returns:
+//   - `bool`: `true` if authentication is required, or `false` otherwise.
+func (nd *TNetworkDecider) NeedAuthentication(aRequest *http.Request) bool {
+	addr := nd.proxies.ClientIP(aRequest)
+	if !addr.IsValid() {
+		return true
+	}
+
+	nd.mtx.RLock()
+	denied, allowed := containsAddr(nd.deny, addr), containsAddr(nd.allow, addr)
+	nd.mtx.RUnlock()
+
+	switch {
+	case denied:
+		return true
+	case allowed:
+		return false
+	case nil != nd.next:
+		return nd.next.NeedAuthentication(aRequest)
+	}
+
+	return true
+} // NeedAuthentication()
+
+// --------------------------------------------------------------------------
+// `TPathDecider` methods:
+
+// `add()` appends a rule for `aPattern`.
+//
+// Parameters:
+//   - `aPattern`: The (optionally method qualified) path pattern.
+//   - `aNeed`: Whether matching requests need authentication.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (pd *TPathDecider) add(aPattern string, aNeed bool) error {
+	rule, err := newPathRule(aPattern)
+	if nil != err {
+		return err
+	}
+	rule.need = aNeed
+
+	pd.mtx.Lock()
+	pd.rules = append(pd.rules, rule)
+	pd.mtx.Unlock()
+
+	return nil
+} // add()
+
+// `Exclude()` appends a rule exempting requests matching `aPattern`
+// from authentication.
+//
+// Parameters:
+//   - `aPattern`: The (optionally method qualified) path pattern.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (pd *TPathDecider) Exclude(aPattern string) error {
+	if err := pd.add(aPattern, false); nil != err {
+		return se.New(err, 1)
+	}
+
+	return nil
+} // Exclude()
+
+// `Include()` appends a rule requiring authentication for requests
+// matching `aPattern`.
+//
+// Parameters:
+//   - `aPattern`: The (optionally method qualified) path pattern.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (pd *TPathDecider) Include(aPattern string) error {
+	if err := pd.add(aPattern, true); nil != err {
+		return se.New(err, 1)
+	}
+
+	return nil
+} // Include()
+
+// `match()` returns the decision of the first rule matching `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: holds the URL to check.
+//
+// Returns:
+//   - `bool`: Whether the request needs authentication.
+//   - `bool`: `true` if a rule matched, or `false` otherwise.
+func (pd *TPathDecider) match(aRequest *http.Request) (bool, bool) {
+	if (nil == aRequest) || (nil == aRequest.URL) {
+		return false, false
+	}
+	reqPath := cleanPath(aRequest.URL.Path)
+
+	pd.mtx.RLock()
+	defer pd.mtx.RUnlock()
+
+	for _, rule := range pd.rules {
+		if rule.matches(aRequest.Method, reqPath) {
+			return rule.need, true
+		}
+	}
+
+	return false, false
+} // match()
+
+// `NeedAuthentication()` returns whether `aRequest` needs
+// authentication according to the first matching rule.
+//
+// Parameters:
+//   - `aRequest`: holds the URL to check.
+//
+// Returns:
+//   - `bool`: `true` if authentication is required, or `false` otherwise.
+func (pd *TPathDecider) NeedAuthentication(aRequest *http.Request) bool {
+	if need, ok := pd.match(aRequest); ok {
+		return need
+	}
+
+	pd.mtx.RLock()
+	defer pd.mtx.RUnlock()
+
+	return pd.dflt
+} // NeedAuthentication()
+
+// `SetDefault()` sets the result for requests not matching any rule.
+//
+// Parameters:
+//   - `aNeed`: Whether those requests need authentication.
+//
+// Returns:
+//   - `*TPathDecider`: The decider itself, allowing method chaining.
+func (pd *TPathDecider) SetDefault(aNeed bool) *TPathDecider {
+	pd.mtx.Lock()
+	pd.dflt = aNeed
+	pd.mtx.Unlock()
+
+	return pd
+} // SetDefault()
+
+// --------------------------------------------------------------------------
+// `tPathRule` methods:
+
+// `matches()` returns whether the rule matches `aMethod` and `aPath`.
+//
+// Parameters:
+//   - `aMethod`: The request's HTTP method.
+//   - `aPath`: The request's cleaned path.
+//
+// Returns:
+//   - `bool`: `true` if the rule matches, or `false` otherwise.
+func (r tPathRule) matches(aMethod, aPath string) bool {
+	if ("" != r.method) && (r.method != aMethod) &&
+		!((http.MethodGet == r.method) && (http.MethodHead == aMethod)) {
+		return false
+	}
+
+	switch r.kind {
+	case ruleExact:
+		return aPath == r.pattern
+	case rulePrefix:
+		return strings.HasPrefix(aPath, r.pattern) ||
+			(aPath == strings.TrimSuffix(r.pattern, "/"))
+	case ruleGlob:
+		ok, _ := path.Match(r.pattern, aPath)
+		return ok
+	case ruleMux:
+		return muxMatch(r.segs, aPath)
+	}
+
+	return false
+} // matches()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `cleanPath()` returns the canonical form of `aPath`, keeping
+// a trailing slash.
+//
+// Parameters:
+//   - `aPath`: The path to clean.
+//
+// Returns:
+//   - `string`: The cleaned path.
+func cleanPath(aPath string) string {
+	if "" == aPath {
+		return "/"
+	}
+	if '/' != aPath[0] {
+		aPath = "/" + aPath
+	}
+	result := path.Clean(aPath)
+	if ('/' == aPath[len(aPath)-1]) && ("/" != result) {
+		result += "/"
+	}
+
+	return result
+} // cleanPath()
+
+// `IsPreflight()` returns whether `aRequest` is a CORS preflight
+// request, i.e. an `OPTIONS` request carrying both the `Origin` and
+// the `Access-Control-Request-Method` header.
+//
+// Browsers never send credentials with preflight requests, so those
+// requests must not be challenged; use this function in your own
+// [IAuthDecider] implementations to exempt them.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `bool`: `true` if the request is a CORS preflight, or `false` otherwise.
+func IsPreflight(aRequest *http.Request) bool {
+	return (nil != aRequest) && (http.MethodOptions == aRequest.Method) &&
+		("" != aRequest.Header.Get("Origin")) &&
+		("" != aRequest.Header.Get("Access-Control-Request-Method"))
+} // IsPreflight()
+
+// `muxMatch()` returns whether `aPath` matches the `ServeMux` pattern
+// segments `aSegments`.
+//
+// Parameters:
+//   - `aSegments`: The pattern's segments (see [newPathRule]).
+//   - `aPath`: The request's cleaned path.
+//
+// Returns:
+//   - `bool`: `true` if the path matches, or `false` otherwise.
+func muxMatch(aSegments []string, aPath string) bool {
+	parts := strings.Split(aPath[1:], "/")
+	for idx, seg := range aSegments {
+		switch {
+		case strings.HasSuffix(seg, "...}"):
+			// matches the remaining path (if any)
+			return idx < len(parts)
+		case "{$}" == seg:
+			// matches the trailing slash only
+			return (idx == len(parts)-1) && ("" == parts[idx])
+		case idx >= len(parts):
+			return false
+		case "" == seg:
+			// trailing slash: matches everything below
+			return true
+		case '{' == seg[0]:
+			if "" == parts[idx] {
+				return false
+			}
+		case seg != parts[idx]:
+			return false
+		}
+	}
+
+	return len(parts) == len(aSegments)
+} // muxMatch()
+
+// `newPathRule()` parses `aPattern` returning a new rule.
+//
+// Parameters:
+//   - `aPattern`: The (optionally method qualified) path pattern.
+//
+// Returns:
+//   - `tPathRule`: The parsed rule.
+//   - `error`: A possible error during processing the request.
+func newPathRule(aPattern string) (tPathRule, error) {
+	var rule tPathRule
+
+	pattern := strings.TrimSpace(aPattern)
+	if method, rest, ok := strings.Cut(pattern, " "); ok && !strings.HasPrefix(method, "/") {
+		if 0 <= strings.IndexFunc(method, func(aRune rune) bool {
+			return ('A' > aRune) || ('Z' < aRune)
+		}) {
+			return rule, fmt.Errorf("invalid method '%s'", method)
+		}
+		rule.method, pattern = method, strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return rule, fmt.Errorf("pattern '%s' doesn't start with a slash", aPattern)
+	}
+	rule.pattern = pattern
+
+	switch {
+	case strings.Contains(pattern, "{"):
+		rule.kind = ruleMux
+		rule.segs = strings.Split(pattern[1:], "/")
+		last := len(rule.segs) - 1
+		for idx, seg := range rule.segs {
+			if !strings.ContainsAny(seg, "{}") {
+				continue
+			}
+			if ('{' != seg[0]) || ('}' != seg[len(seg)-1]) || (3 > len(seg)) ||
+				strings.ContainsAny(seg[1:len(seg)-1], "{}") {
+				return rule, fmt.Errorf("invalid wildcard '%s' in '%s'", seg, aPattern)
+			}
+			if (strings.HasSuffix(seg, "...}") || ("{$}" == seg)) && (idx != last) {
+				return rule, fmt.Errorf("wildcard '%s' not at the end of '%s'", seg, aPattern)
+			}
+		}
+
+	case strings.HasSuffix(pattern, "/**") && !strings.ContainsAny(pattern[:len(pattern)-3], "*?[\\"):
+		rule.kind, rule.pattern = rulePrefix, pattern[:len(pattern)-2]
+
+	case strings.ContainsAny(pattern, "*?[\\"):
+		if _, err := path.Match(pattern, ""); nil != err {
+			return rule, fmt.Errorf("invalid pattern '%s': %w", aPattern, err)
+		}
+		rule.kind = ruleGlob
+
+	case strings.HasSuffix(pattern, "/"):
+		rule.kind = rulePrefix
+
+	default:
+		rule.kind = ruleExact
+	}
+
+	return rule, nil
+} // newPathRule()
+
+/* _EoF_ */
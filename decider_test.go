@@ -0,0 +1,240 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_TMethodDecider_NeedAuthentication(t *testing.T) {
+	pd := NewPathDecider().SetDefault(false)
+	_ = pd.Include("/admin/")
+	public := NewMethodDecider(nil)
+	paths := NewMethodDecider(pd)
+	getOnly := NewMethodDecider(nil, "get")
+
+	tests := []struct {
+		name      string
+		decider   *TMethodDecider
+		method    string
+		target    string
+		preflight bool
+		want      bool
+	}{
+		{" 1", public, "GET", "/page", false, false},
+		{" 2", public, "HEAD", "/page", false, false},
+		{" 3", public, "OPTIONS", "/page", false, false},
+		{" 4", public, "POST", "/page", false, true},
+		{" 5", public, "DELETE", "/page", false, true},
+		{" 6", public, "TRACE", "/page", false, true},
+		{" 7", paths, "GET", "/page", false, false},
+		{" 8", paths, "GET", "/admin/users", false, true},
+		{" 9", paths, "HEAD", "/admin/users", false, true},
+		{"10", paths, "PUT", "/page", false, true},
+		{"11", paths, "OPTIONS", "/admin/users", true, false},
+		{"12", getOnly, "HEAD", "/page", false, false},
+		{"13", getOnly, "OPTIONS", "/page", false, true},
+		{"14", getOnly, "OPTIONS", "/page", true, false},
+		{"15", getOnly, "PATCH", "/page", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.target, nil)
+			if tt.preflight {
+				req.Header.Set("Origin", "https://app.example.com")
+				req.Header.Set("Access-Control-Request-Method", "DELETE")
+			}
+			if got := tt.decider.NeedAuthentication(req); got != tt.want {
+				t.Errorf("TMethodDecider.NeedAuthentication(%s %s) = %v, want %v",
+					tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+} // Test_TMethodDecider_NeedAuthentication()
+
+func Test_TNetworkDecider_NeedAuthentication(t *testing.T) {
+	proxies, _ := NewTrustedProxies("10.0.0.0/8")
+	nd := NewNetworkDecider(proxies, nil)
+	if err := nd.Allow("192.168.0.0/16", "fd00::/8", "203.0.113.7"); nil != err {
+		t.Fatalf("TNetworkDecider.Allow() error = %v", err)
+	}
+	if err := nd.Deny("192.168.66.0/24", "fd00:bad::/32"); nil != err {
+		t.Fatalf("TNetworkDecider.Deny() error = %v", err)
+	}
+	if err := nd.Allow("192.168.0.0/33"); nil == err {
+		t.Error("TNetworkDecider.Allow() expected error for invalid network")
+	}
+	md := NewNetworkDecider(nil, NewMethodDecider(nil))
+	_ = md.Allow("192.168.0.0/16")
+
+	tests := []struct {
+		name    string
+		decider *TNetworkDecider
+		method  string
+		remote  string
+		xff     string
+		want    bool
+	}{
+		{" 1", nd, "GET", "192.168.1.2:1234", "", false},
+		{" 2", nd, "GET", "192.168.66.2:1234", "", true},
+		{" 3", nd, "GET", "[fd00::1]:1234", "", false},
+		{" 4", nd, "GET", "[fd00:bad::1]:1234", "", true},
+		{" 5", nd, "GET", "[::ffff:192.168.1.2]:1234", "", false},
+		{" 6", nd, "GET", "198.51.100.7:1234", "", true},
+		{" 7", nd, "GET", "10.1.2.3:1234", "192.168.1.2", false},
+		{" 8", nd, "GET", "198.51.100.7:1234", "192.168.1.2", true},
+		{" 9", nd, "GET", "10.1.2.3:1234", "garbage", true},
+		{"10", nd, "GET", "203.0.113.7:1234", "", false},
+		{"11", md, "POST", "192.168.1.2:1234", "", false},
+		{"12", md, "GET", "198.51.100.7:1234", "", false},
+		{"13", md, "POST", "198.51.100.7:1234", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com/", nil)
+			req.RemoteAddr = tt.remote
+			if "" != tt.xff {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := tt.decider.NeedAuthentication(req); got != tt.want {
+				t.Errorf("TNetworkDecider.NeedAuthentication(%s) = %v, want %v",
+					tt.remote, got, tt.want)
+			}
+		})
+	}
+} // Test_TNetworkDecider_NeedAuthentication()
+
+func Test_newPathRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		wantKind uint8
+		wantErr  bool
+	}{
+		{" 1", "/login", ruleExact, false},
+		{" 2", "/static/", rulePrefix, false},
+		{" 3", "/assets/**", rulePrefix, false},
+		{" 4", "/img/*.png", ruleGlob, false},
+		{" 5", "/users/{id}", ruleMux, false},
+		{" 6", "POST /api/", rulePrefix, false},
+		{" 7", "login", 0, true},
+		{" 8", "post /api/", 0, true},
+		{" 9", "/files/{path...}/x", 0, true},
+		{"10", "/users/{id", 0, true},
+		{"11", "/users/x{id}", 0, true},
+		{"12", "/img/[a-", 0, true},
+		{"13", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newPathRule(tt.pattern)
+			if (nil != err) != tt.wantErr {
+				t.Errorf("newPathRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.kind != tt.wantKind) {
+				t.Errorf("newPathRule() kind = %d, want %d", got.kind, tt.wantKind)
+			}
+		})
+	}
+} // Test_newPathRule()
+
+func Test_TPathDecider_NeedAuthentication(t *testing.T) {
+	pd := NewPathDecider()
+	for _, pattern := range []string{
+		"/static/admin/",
+		"GET /users/{id}/edit",
+	} {
+		if err := pd.Include(pattern); nil != err {
+			t.Fatalf("TPathDecider.Include() error = %v", err)
+		}
+	}
+	for _, pattern := range []string{
+		"/static/",
+		"/login",
+		"/img/*.png",
+		"/assets/**",
+		"GET /users/{id}/{rest...}",
+		"/{$}",
+	} {
+		if err := pd.Exclude(pattern); nil != err {
+			t.Fatalf("TPathDecider.Exclude() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   bool
+	}{
+		{" 1", "GET", "/", false},
+		{" 2", "GET", "/static", false},
+		{" 3", "GET", "/static/app.css", false},
+		{" 4", "GET", "/static/admin/app.css", true},
+		{" 5", "GET", "/static/../admin/", true},
+		{" 6", "GET", "//static/admin/x", true},
+		{" 7", "POST", "/login", false},
+		{" 8", "GET", "/login/", true},
+		{" 9", "GET", "/img/logo.png", false},
+		{"10", "GET", "/img/sub/logo.png", true},
+		{"11", "GET", "/assets/js/app.js", false},
+		{"12", "GET", "/users/42/edit", true},
+		{"13", "HEAD", "/users/42/profile", false},
+		{"14", "POST", "/users/42/profile", true},
+		{"15", "GET", "/users/42", true},
+		{"16", "GET", "/admin", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com/", nil)
+			req.URL.Path = tt.target
+			if got := pd.NeedAuthentication(req); got != tt.want {
+				t.Errorf("TPathDecider.NeedAuthentication(%s %s) = %v, want %v",
+					tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+
+	if pd.SetDefault(false).NeedAuthentication(httptest.NewRequest("GET", "/admin", nil)) {
+		t.Error("TPathDecider.NeedAuthentication() ignored the default")
+	}
+} // Test_TPathDecider_NeedAuthentication()
+
+func Test_LoadPathDecider(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.rules")
+	_ = os.WriteFile(good, []byte(`# protect the admin area only
+include /admin/
+; comment
+exclude GET /api/{version}/status
+default exclude
+`), 0600)
+	bad := filepath.Join(dir, "bad.rules")
+	_ = os.WriteFile(bad, []byte("include /admin/\nprotect /x\n"), 0600)
+
+	pd, err := LoadPathDecider(good)
+	if nil != err {
+		t.Fatalf("LoadPathDecider() error = %v", err)
+	}
+	if !pd.NeedAuthentication(httptest.NewRequest("GET", "/admin/users", nil)) ||
+		pd.NeedAuthentication(httptest.NewRequest("GET", "/api/v1/status", nil)) ||
+		pd.NeedAuthentication(httptest.NewRequest("GET", "/index.html", nil)) {
+		t.Error("LoadPathDecider() rules not applied")
+	}
+
+	for _, name := range []string{bad, filepath.Join(dir, "missing.rules"), ""} {
+		if _, err := LoadPathDecider(name); nil == err {
+			t.Errorf("LoadPathDecider(%q) expected error", name)
+		}
+	}
+} // Test_LoadPathDecider()
+
+/* _EoF_ */
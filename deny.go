@@ -0,0 +1,282 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides a denial response renderer choosing between
+ * plain text, HTML and RFC 9457 problem details by the request's
+ * `Accept` header.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// Media types offered by [TDenyRenderer] (in order of preference).
+	mediaPlain   = "text/plain"
+	mediaHTML    = "text/html"
+	mediaProblem = "application/problem+json"
+	mediaJSON    = "application/json"
+)
+
+type (
+	// `TDenyPage` holds the data of a denial response; it's passed
+	// to the HTML template of a [TDenyRenderer].
+	TDenyPage struct {
+		Type       string `json:"type"`                 // URI identifying the problem type
+		Status     int    `json:"status"`               // HTTP status code
+		Title      string `json:"title"`                // short summary of the problem
+		Detail     string `json:"detail"`               // human-readable explanation
+		Instance   string `json:"instance,omitempty"`   // path of the denied request
+		RetryAfter int    `json:"retryAfter,omitempty"` // seconds to wait (if any)
+	}
+
+	// `TDenyRenderer` writes the denial responses of the middleware
+	// in the format preferred by the remote host: RFC 9457 problem
+	// details (`application/problem+json` or `application/json`),
+	// an HTML page (`text/html`), or plain text (the default).
+	//
+	// Use its [TDenyRenderer.Deny] method with [WithDenyHandler].
+	TDenyRenderer struct {
+		html     *template.Template // template of the HTML pages
+		typeBase string             // base URI of the problem types
+	}
+)
+
+// `denyDetails` holds the explanations of the denial status codes.
+var denyDetails = map[int]struct{ slug, detail string }{
+	http.StatusUnauthorized: {"unauthorised",
+		"Valid credentials are required to access this resource."},
+	http.StatusForbidden: {"forbidden",
+		"You are authenticated but not allowed to access this resource."},
+	http.StatusProxyAuthRequired: {"proxy-unauthorised",
+		"Valid proxy credentials are required to access this resource."},
+	http.StatusLocked: {"locked",
+		"This account is temporarily locked because of too many failed authentications."},
+	http.StatusTooManyRequests: {"throttled",
+		"Too many failed authentications from your address; please try again later."},
+	http.StatusServiceUnavailable: {"unavailable",
+		"The authentication is temporarily unavailable; please try again later."},
+}
+
+// `denyTemplate` is the default HTML template of [TDenyRenderer].
+var denyTemplate = template.Must(template.New("deny").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Status}} {{.Title}}</title>
+</head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>{{.Detail}}</p>
+{{- if .RetryAfter}}
+<p>Please retry in {{.RetryAfter}} seconds.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// `NewDenyRenderer()` returns a new denial response renderer.
+//
+// The HTML pages are produced by executing `aTemplate` with a
+// [TDenyPage]; if `aTemplate` is `nil` a simple built-in page is
+// used. Use your own template to send pages matching your site's
+// design.
+//
+// Parameters:
+//   - `aTemplate`: The template of the HTML pages (may be `nil`).
+//
+// Returns:
+//   - `*TDenyRenderer`: The new renderer.
+func NewDenyRenderer(aTemplate *template.Template) *TDenyRenderer {
+	if nil == aTemplate {
+		aTemplate = denyTemplate
+	}
+
+	return &TDenyRenderer{
+		html: aTemplate,
+	}
+} // NewDenyRenderer()
+
+// `Deny()` writes the denial response for `aStatus` in the format
+// preferred by the remote host's `Accept` header.
+//
+// The method's signature matches [TDenyHandler], so it can be
+// passed to [WithDenyHandler] directly.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aStatus`: The HTTP status code to send.
+func (dr *TDenyRenderer) Deny(aWriter http.ResponseWriter, aRequest *http.Request, aStatus int) {
+	page := dr.page(aWriter, aRequest, aStatus)
+	media := negotiate(aRequest.Header.Get("Accept"),
+		mediaPlain, mediaHTML, mediaProblem, mediaJSON)
+
+	var body []byte
+	switch media {
+	case mediaProblem, mediaJSON:
+		body, _ = json.Marshal(page) // can't fail for this type
+		body = append(body, '\n')
+
+	case mediaHTML:
+		var buf bytes.Buffer
+		if err := dr.html.Execute(&buf, page); nil == err {
+			body = buf.Bytes()
+			break
+		}
+		media = mediaPlain
+		fallthrough
+
+	default:
+		body = []byte(strconv.Itoa(page.Status) + " " + page.Title +
+			"\n\n" + page.Detail + "\n")
+	}
+
+	header := aWriter.Header()
+	if mediaPlain == media || mediaHTML == media {
+		media += "; charset=utf-8"
+	}
+	header.Set("Content-Type", media)
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", "no-store")
+	header.Add("Vary", "Accept")
+	aWriter.WriteHeader(aStatus)
+	_, _ = aWriter.Write(body)
+} // Deny()
+
+// `page()` returns the data describing the denial of `aRequest`.
+//
+// Parameters:
+//   - `aWriter`: The response writer (carrying the headers already set).
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aStatus`: The HTTP status code to send.
+//
+// Returns:
+//   - `TDenyPage`: The response's data.
+func (dr *TDenyRenderer) page(aWriter http.ResponseWriter, aRequest *http.Request, aStatus int) TDenyPage {
+	result := TDenyPage{
+		Type:   "about:blank",
+		Status: aStatus,
+		Title:  http.StatusText(aStatus),
+		Detail: "The request was denied.",
+	}
+	if http.StatusUnauthorized == aStatus {
+		result.Title = "Unauthorised"
+	}
+	if d, ok := denyDetails[aStatus]; ok {
+		result.Detail = d.detail
+		if "" != dr.typeBase {
+			result.Type = dr.typeBase + d.slug
+		}
+	}
+	if nil != aRequest.URL {
+		result.Instance = aRequest.URL.Path
+	}
+	if secs, err := strconv.Atoi(aWriter.Header().Get("Retry-After")); nil == err {
+		result.RetryAfter = secs
+	}
+
+	return result
+} // page()
+
+// `SetTypeBase()` sets the base URI of the problem types.
+//
+// By default the problem details use "about:blank" as their type.
+// With a base URI set, the types are the base URI followed by
+// "unauthorised", "forbidden", "proxy-unauthorised", "locked",
+// "throttled", or "unavailable" respectively, e.g. to link to a
+// documentation page.
+//
+// Parameters:
+//   - `aURI`: The base URI (e.g. "https://example.com/problems/").
+//
+// Returns:
+//   - `*TDenyRenderer`: The renderer itself, allowing method chaining.
+func (dr *TDenyRenderer) SetTypeBase(aURI string) *TDenyRenderer {
+	dr.typeBase = strings.TrimSpace(aURI)
+
+	return dr
+} // SetTypeBase()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `negotiate()` returns the media type out of `aOffers` preferred
+// by the `Accept` header value `aAccept`.
+//
+// Each offer gets the quality of the most specific media range
+// matching it; the offer with the highest quality wins, ties are
+// broken by the order of `aOffers`. If `aAccept` is empty or no
+// offer is acceptable the first offer is returned.
+//
+// Parameters:
+//   - `aAccept`: The value of a request's `Accept` header.
+//   - `aOffers`: The media types available (in order of preference).
+//
+// Returns:
+//   - `string`: The media type to send.
+func negotiate(aAccept string, aOffers ...string) string {
+	if 0 == len(aOffers) {
+		return ""
+	}
+	if aAccept = strings.TrimSpace(aAccept); "" == aAccept {
+		return aOffers[0]
+	}
+
+	result, best := aOffers[0], 0.0
+	for _, offer := range aOffers {
+		quality, specific := 0.0, -1
+		for _, part := range strings.Split(aAccept, ",") {
+			params := strings.Split(part, ";")
+			media := strings.ToLower(strings.TrimSpace(params[0]))
+
+			var level int
+			switch {
+			case media == offer:
+				level = 2
+			case strings.HasSuffix(media, "/*") &&
+				strings.HasPrefix(offer, strings.TrimSuffix(media, "*")):
+				level = 1
+			case "*/*" == media:
+				level = 0
+			default:
+				continue
+			}
+			if level <= specific {
+				continue
+			}
+
+			q := 1.0
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if "q" == strings.ToLower(strings.TrimSpace(name)) {
+					if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); nil == err {
+						q = f
+					}
+				}
+			}
+			quality, specific = q, level
+		}
+		if quality > best {
+			result, best = offer, quality
+		}
+	}
+
+	return result
+} // negotiate()
+
+/* _EoF_ */
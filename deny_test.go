@@ -0,0 +1,136 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_negotiate(t *testing.T) {
+	offers := []string{mediaPlain, mediaHTML, mediaProblem, mediaJSON}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{" 1", "", mediaPlain},
+		{" 2", "*/*", mediaPlain},
+		{" 3", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", mediaHTML},
+		{" 4", "application/json", mediaJSON},
+		{" 5", "application/problem+json, application/json;q=0.9", mediaProblem},
+		{" 6", "application/*", mediaProblem},
+		{" 7", "text/*;q=0.5, application/json", mediaJSON},
+		{" 8", "image/png", mediaPlain},
+		{" 9", "text/plain;q=0, */*", mediaHTML},
+		{"10", "TEXT/HTML; Q=0.7, text/plain;q=0.3", mediaHTML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.accept, offers...); got != tt.want {
+				t.Errorf("negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+} // Test_negotiate()
+
+func Test_TDenyRenderer_Deny(t *testing.T) {
+	brand := template.Must(template.New("brand").Parse(
+		`<p class="brand">{{.Status}}: {{.Detail}}</p>`))
+
+	tests := []struct {
+		name     string
+		renderer *TDenyRenderer
+		accept   string
+		status   int
+		wantType string
+		wantBody string
+	}{
+		{" 1", NewDenyRenderer(nil), "", http.StatusUnauthorized,
+			mediaPlain, "401 Unauthorised\n\nValid credentials"},
+		{" 2", NewDenyRenderer(nil), "text/html", http.StatusForbidden,
+			mediaHTML, "<h1>403 Forbidden</h1>"},
+		{" 3", NewDenyRenderer(brand), "text/html", http.StatusLocked,
+			mediaHTML, `<p class="brand">423: This account is temporarily locked`},
+		{" 4", NewDenyRenderer(nil), "application/problem+json", http.StatusTooManyRequests,
+			mediaProblem, `"type":"about:blank","status":429`},
+		{" 5", NewDenyRenderer(nil).SetTypeBase("https://example.com/problems/"),
+			"application/json", http.StatusLocked,
+			mediaJSON, `"type":"https://example.com/problems/locked"`},
+		{" 6", NewDenyRenderer(nil), "application/json", http.StatusTooManyRequests,
+			mediaJSON, `"retryAfter":30`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/secret", nil)
+			if "" != tt.accept {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Retry-After", "30")
+			tt.renderer.Deny(rec, req, tt.status)
+
+			if rec.Code != tt.status {
+				t.Errorf("TDenyRenderer.Deny() status = %d, want %d",
+					rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("TDenyRenderer.Deny() Content-Type = %q, want %q",
+					got, tt.wantType)
+			}
+			if got := rec.Body.String(); !strings.Contains(got, tt.wantBody) {
+				t.Errorf("TDenyRenderer.Deny() body = %q, want %q",
+					got, tt.wantBody)
+			}
+		})
+	}
+} // Test_TDenyRenderer_Deny()
+
+func Test_TDenyRenderer_problem(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/api/data", nil)
+	req.Header.Set("Accept", mediaProblem)
+	rec := httptest.NewRecorder()
+	NewDenyRenderer(nil).Deny(rec, req, http.StatusUnauthorized)
+
+	var got TDenyPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); nil != err {
+		t.Fatalf("TDenyRenderer.Deny() invalid JSON: %v", err)
+	}
+	want := TDenyPage{
+		Type:     "about:blank",
+		Status:   http.StatusUnauthorized,
+		Title:    "Unauthorised",
+		Detail:   denyDetails[http.StatusUnauthorized].detail,
+		Instance: "/api/data",
+	}
+	if got != want {
+		t.Errorf("TDenyRenderer.Deny() = %+v, want %+v", got, want)
+	}
+} // Test_TDenyRenderer_problem()
+
+func Test_TMiddleware_Forbid(t *testing.T) {
+	mw := NewMiddleware(WithDenyHandler(NewDenyRenderer(nil).Deny))
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Accept", mediaJSON)
+	rec := httptest.NewRecorder()
+	mw.Forbid(rec, req)
+
+	if http.StatusForbidden != rec.Code {
+		t.Errorf("TMiddleware.Forbid() status = %d, want %d",
+			rec.Code, http.StatusForbidden)
+	}
+	if !strings.Contains(rec.Body.String(), `"status":403`) {
+		t.Errorf("TMiddleware.Forbid() body = %q", rec.Body.String())
+	}
+} // Test_TMiddleware_Forbid()
+
+/* _EoF_ */
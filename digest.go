@@ -0,0 +1,496 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the HTTP Digest authentication (RFC 7616).
+ */
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// Length of a nonce's random part.
+	nonceRandLen = 8
+
+	// Length of a nonce's signature.
+	nonceMACLen = 16
+
+	// Length of a raw (i.e. not encoded) nonce.
+	nonceLen = 8 + nonceRandLen + nonceMACLen
+)
+
+var (
+	// `ErrStaleNonce` is returned if the credentials were computed
+	// using an expired nonce; the client should simply retry using
+	// the new nonce sent along with the next challenge.
+	ErrStaleNonce = errors.New("stale digest nonce")
+)
+
+type (
+	// `TDigestAuth` implements the HTTP Digest authentication
+	// (RFC 7616) with `qop=auth` for a single realm.
+	//
+	// The server keeps no state for the nonces it hands out: each
+	// nonce carries its creation time and is signed by a random key
+	// created along with the instance. Only nonces actually used for
+	// a successful authentication are remembered (until they expire)
+	// to reject replayed requests by their nonce count.
+	TDigestAuth struct {
+		mtx        sync.Mutex        // protect the nonce counters
+		store      *TDigestStore     // the HA1 values of the users
+		realm      string            // the protection space
+		algorithms []string          // the algorithms to offer
+		key        []byte            // HMAC key signing the nonces
+		opaque     string            // opaque value sent to clients
+		ttl        time.Duration     // lifetime of a nonce
+		counters   map[string]uint64 // highest nonce count seen by nonce
+		swept      time.Time         // time of the latest cleanup
+	}
+)
+
+// `NewDigestAuth()` returns a new Digest authenticator for `aRealm`
+// using the HA1 values provided by `aStore`.
+//
+// If `aTTL` isn't positive the nonces are valid for five minutes.
+// If no `aAlgorithms` are given both [DigestSHA256] and [DigestMD5]
+// are offered (in this order); unknown algorithm names are ignored.
+//
+// If either `aStore` is `nil` or `aRealm` is empty the function
+// returns `nil`.
+//
+// Parameters:
+//   - `aStore`: The HA1 values to use.
+//   - `aRealm`: The protection space to use.
+//   - `aTTL`: The lifetime of a nonce.
+//   - `aAlgorithms`: The digest algorithms to offer.
+//
+// Returns:
+//   - `*TDigestAuth`: The new authenticator.
+func NewDigestAuth(aStore *TDigestStore, aRealm string, aTTL time.Duration, aAlgorithms ...string) *TDigestAuth {
+	if (nil == aStore) || ("" == strings.TrimSpace(aRealm)) {
+		return nil
+	}
+	if 0 >= aTTL {
+		aTTL = 5 * time.Minute
+	}
+
+	algorithms := make([]string, 0, 2)
+	for _, algo := range aAlgorithms {
+		if algo = strings.ToUpper(strings.TrimSpace(algo)); (DigestSHA256 == algo) || (DigestMD5 == algo) {
+			if !slices.Contains(algorithms, algo) {
+				algorithms = append(algorithms, algo)
+			}
+		}
+	}
+	if 0 == len(algorithms) {
+		algorithms = append(algorithms, DigestSHA256, DigestMD5)
+	}
+
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+	opaque := make([]byte, 16)
+	_, _ = rand.Read(opaque)
+
+	return &TDigestAuth{
+		store:      aStore,
+		realm:      aRealm,
+		algorithms: algorithms,
+		key:        key,
+		opaque:     base64.RawURLEncoding.EncodeToString(opaque),
+		ttl:        aTTL,
+		counters:   make(map[string]uint64, 64),
+		swept:      time.Now(),
+	}
+} // NewDigestAuth()
+
+// --------------------------------------------------------------------------
+// `TDigestAuth` methods:
+
+// `Authenticate()` checks the Digest credentials of `aRequest`.
+//
+// Unknown users and wrong passwords both result in
+// `ErrInvalidCredentials`, as do replayed requests. If the nonce
+// used has expired `ErrStaleNonce` is returned instead.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `string`: The authenticated user's name.
+//   - `error`: A possible error during processing the request.
+func (da *TDigestAuth) Authenticate(aRequest *http.Request) (string, error) {
+	return da.authenticate(aRequest, "Authorization")
+} // Authenticate()
+
+// `authenticate()` checks the Digest credentials in the `aHeader`
+// header of `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aHeader`: Either "Authorization" or "Proxy-Authorization".
+//
+// Returns:
+//   - `string`: The authenticated user's name.
+//   - `error`: A possible error during processing the request.
+func (da *TDigestAuth) authenticate(aRequest *http.Request, aHeader string) (string, error) {
+	if (nil == da) || (nil == aRequest) {
+		return "", ErrInvalidCredentials
+	}
+	params, ok := parseDigest(aRequest.Header.Get(aHeader))
+	if !ok {
+		return "", ErrInvalidCredentials
+	}
+
+	algo := strings.ToUpper(params["algorithm"])
+	if "" == algo {
+		algo = DigestMD5 // RFC 7616, 3.4
+	}
+	if !slices.Contains(da.algorithms, algo) ||
+		("auth" != params["qop"]) ||
+		(params["realm"] != da.realm) ||
+		("" == params["cnonce"]) ||
+		(("" != params["opaque"]) && (params["opaque"] != da.opaque)) {
+		return "", ErrInvalidCredentials
+	}
+	if !digestURIMatches(params["uri"], aRequest) {
+		return "", ErrInvalidCredentials
+	}
+	nc, err := strconv.ParseUint(params["nc"], 16, 64)
+	if (nil != err) || (0 == nc) {
+		return "", ErrInvalidCredentials
+	}
+
+	nonce := params["nonce"]
+	issued, ok := da.checkNonce(nonce)
+	if !ok {
+		return "", ErrInvalidCredentials
+	}
+
+	user := digestUser(params)
+	ha1, err := da.store.Find(user, da.realm, algo)
+	if nil != err {
+		return "", ErrInvalidCredentials
+	}
+	ha2 := digestHash(algo, aRequest.Method+":"+params["uri"])
+	want := digestHash(algo, ha1+":"+nonce+":"+params["nc"]+":"+
+		params["cnonce"]+":"+params["qop"]+":"+ha2)
+	if 1 != subtle.ConstantTimeCompare([]byte(want), []byte(strings.ToLower(params["response"]))) {
+		return "", ErrInvalidCredentials
+	}
+
+	// Only now, with valid credentials, it's worth to check
+	// the nonce's age and count.
+	if time.Since(issued) > da.ttl {
+		return "", ErrStaleNonce
+	}
+	if !da.useNonce(nonce, nc, issued) {
+		return "", ErrInvalidCredentials
+	}
+
+	return user, nil
+} // authenticate()
+
+// `AuthenticateProxy()` works like [TDigestAuth.Authenticate] but
+// checks the `Proxy-Authorization` header sent to a forward proxy.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a proxy.
+//
+// Returns:
+//   - `string`: The authenticated user's name.
+//   - `error`: A possible error during processing the request.
+func (da *TDigestAuth) AuthenticateProxy(aRequest *http.Request) (string, error) {
+	return da.authenticate(aRequest, "Proxy-Authorization")
+} // AuthenticateProxy()
+
+// `Challenges()` returns the values of the `WWW-Authenticate` headers
+// asking for Digest authentication, one per configured algorithm.
+//
+// Parameters:
+//   - `aStale`: Whether the client's previous nonce had expired.
+//
+// Returns:
+//   - `[]string`: The challenges to send.
+func (da *TDigestAuth) Challenges(aStale bool) []string {
+	if nil == da {
+		return nil
+	}
+	nonce := da.newNonce(time.Now())
+
+	result := make([]string, 0, len(da.algorithms))
+	for _, algo := range da.algorithms {
+		challenge := `Digest realm=` + quoteParam(da.realm) +
+			`, qop="auth", algorithm=` + algo +
+			`, nonce="` + nonce + `", opaque="` + da.opaque + `"`
+		if aStale {
+			challenge += `, stale=true`
+		}
+		result = append(result, challenge+`, charset=UTF-8`)
+	}
+
+	return result
+} // Challenges()
+
+// `checkNonce()` checks the signature of `aNonce`.
+//
+// Parameters:
+//   - `aNonce`: The nonce sent by the client.
+//
+// Returns:
+//   - `time.Time`: The time the nonce was created.
+//   - `bool`: `true` if the nonce was created by us, or `false` otherwise.
+func (da *TDigestAuth) checkNonce(aNonce string) (time.Time, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(aNonce)
+	if (nil != err) || (nonceLen != len(raw)) {
+		return time.Time{}, false
+	}
+	if !hmac.Equal(raw[nonceLen-nonceMACLen:], da.signNonce(raw[:nonceLen-nonceMACLen])) {
+		return time.Time{}, false
+	}
+
+	//#nosec G115 – the value was signed by ourselves
+	return time.Unix(0, int64(binary.BigEndian.Uint64(raw[:8]))), true
+} // checkNonce()
+
+// `newNonce()` returns a new signed nonce created at `aNow`.
+//
+// Parameters:
+//   - `aNow`: The nonce's creation time.
+//
+// Returns:
+//   - `string`: The new nonce.
+func (da *TDigestAuth) newNonce(aNow time.Time) string {
+	raw := make([]byte, nonceLen-nonceMACLen, nonceLen)
+	binary.BigEndian.PutUint64(raw[:8], uint64(aNow.UnixNano())) //#nosec G115
+	_, _ = rand.Read(raw[8:])
+	raw = append(raw, da.signNonce(raw)...)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+} // newNonce()
+
+// `signNonce()` returns the signature of a nonce's `aData`.
+//
+// Parameters:
+//   - `aData`: The nonce's time and random part.
+//
+// Returns:
+//   - `[]byte`: The nonce's signature.
+func (da *TDigestAuth) signNonce(aData []byte) []byte {
+	mac := hmac.New(sha256.New, da.key)
+	mac.Write(aData)
+
+	return mac.Sum(nil)[:nonceMACLen]
+} // signNonce()
+
+// `useNonce()` records the use of `aNonce` with the nonce count `aNC`.
+//
+// Parameters:
+//   - `aNonce`: The nonce used by the client.
+//   - `aNC`: The client's nonce count.
+//   - `aIssued`: The time the nonce was created.
+//
+// Returns:
+//   - `bool`: `true` if the count wasn't used before, or `false` otherwise.
+func (da *TDigestAuth) useNonce(aNonce string, aNC uint64, aIssued time.Time) bool {
+	now := time.Now()
+
+	da.mtx.Lock()
+	defer da.mtx.Unlock()
+
+	if now.Sub(da.swept) > da.ttl {
+		// Forget all nonces that can't be used anymore anyway.
+		da.swept = now
+		for nonce := range da.counters {
+			if issued, ok := da.checkNonce(nonce); !ok || (now.Sub(issued) > da.ttl) {
+				delete(da.counters, nonce)
+			}
+		}
+	}
+
+	if last, ok := da.counters[aNonce]; ok && (aNC <= last) {
+		return false
+	}
+	if now.Sub(aIssued) <= da.ttl {
+		da.counters[aNonce] = aNC
+	}
+
+	return true
+} // useNonce()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `digestURIMatches()` checks whether the `uri` parameter of the
+// Digest credentials denotes the URI of `aRequest`.
+//
+// Parameters:
+//   - `aURI`: The URI given by the client.
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `bool`: `true` if the URIs match, or `false` otherwise.
+func digestURIMatches(aURI string, aRequest *http.Request) bool {
+	if "" == aURI {
+		return false
+	}
+	if (aURI == aRequest.RequestURI) || (aURI == aRequest.URL.RequestURI()) {
+		return true
+	}
+
+	// Some clients send the absolute URI:
+	if u, err := url.Parse(aURI); (nil == err) && u.IsAbs() {
+		return u.RequestURI() == aRequest.URL.RequestURI()
+	}
+
+	return false
+} // digestURIMatches()
+
+// `digestUser()` returns the username of the Digest credentials.
+//
+// Non-ASCII names are sent as `username*` parameter (RFC 8187).
+//
+// Parameters:
+//   - `aParams`: The parsed Digest credentials.
+//
+// Returns:
+//   - `string`: The user's name.
+func digestUser(aParams map[string]string) string {
+	if user, ok := aParams["username"]; ok {
+		return user
+	}
+
+	// RFC 8187: charset'language'percent-encoded-value
+	ext := aParams["username*"]
+	charset, rest, ok := strings.Cut(ext, "'")
+	if !ok || !strings.EqualFold("UTF-8", charset) {
+		return ""
+	}
+	if _, rest, ok = strings.Cut(rest, "'"); !ok {
+		return ""
+	}
+	user, err := url.PathUnescape(rest)
+	if nil != err {
+		return ""
+	}
+
+	return user
+} // digestUser()
+
+// `isDigest()` returns whether the `Authorization` (or
+// `Proxy-Authorization`) header value `aHeader` carries Digest
+// credentials.
+//
+// Parameters:
+//   - `aHeader`: The header value to check.
+//
+// Returns:
+//   - `bool`: `true` if the header uses the Digest scheme.
+func isDigest(aHeader string) bool {
+	return (7 <= len(aHeader)) && strings.EqualFold(aHeader[:7], "Digest ")
+} // isDigest()
+
+// `parseAuthParams()` parses a list of authentication parameters
+// (RFC 7235, 2.1) like `a=b, c="d e"`.
+//
+// The parameter names are returned in lower case.
+//
+// Parameters:
+//   - `aText`: The parameters to parse.
+//
+// Returns:
+//   - `map[string]string`: The parsed parameters.
+//   - `bool`: `true` if the whole text could be parsed.
+func parseAuthParams(aText string) (map[string]string, bool) {
+	result := make(map[string]string, 12)
+	for {
+		aText = strings.TrimLeft(aText, " \t,")
+		if "" == aText {
+			return result, true
+		}
+
+		idx := strings.IndexByte(aText, '=')
+		if 0 >= idx {
+			return nil, false
+		}
+		name := strings.ToLower(strings.TrimSpace(aText[:idx]))
+		aText = strings.TrimLeft(aText[idx+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(aText, `"`) {
+			var sb strings.Builder
+			end := -1
+		scan:
+			for i := 1; i < len(aText); i++ {
+				switch aText[i] {
+				case '\\':
+					if i++; i < len(aText) {
+						sb.WriteByte(aText[i])
+					}
+				case '"':
+					end = i
+					break scan
+				default:
+					sb.WriteByte(aText[i])
+				}
+			}
+			if 0 > end {
+				return nil, false // unterminated quoted string
+			}
+			value, aText = sb.String(), aText[end+1:]
+		} else {
+			idx = strings.IndexByte(aText, ',')
+			if 0 > idx {
+				idx = len(aText)
+			}
+			value, aText = strings.TrimSpace(aText[:idx]), aText[idx:]
+		}
+		result[name] = value
+
+		aText = strings.TrimLeft(aText, " \t")
+		if ("" != aText) && (',' != aText[0]) {
+			return nil, false
+		}
+	}
+} // parseAuthParams()
+
+// `parseDigest()` returns the parameters of the Digest credentials
+// in the value of an `Authorization` header.
+//
+// Parameters:
+//   - `aHeader`: The header value to parse.
+//
+// Returns:
+//   - `map[string]string`: The parsed parameters.
+//   - `bool`: `true` if the header carried Digest credentials.
+func parseDigest(aHeader string) (map[string]string, bool) {
+	const prefix = "Digest "
+
+	if (len(aHeader) < len(prefix)) || !strings.EqualFold(aHeader[:len(prefix)], prefix) {
+		return nil, false
+	}
+
+	return parseAuthParams(aHeader[len(prefix):])
+} // parseDigest()
+
+/* _EoF_ */
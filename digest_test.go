@@ -0,0 +1,256 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// digestHeader is an internal test helper returning the value of
+// an `Authorization` header as computed by a Digest client.
+func digestHeader(aAlgo, aUser, aRealm, aPassword, aMethod, aURI, aNonce, aNC string) string {
+	ha1 := digestHA1(aAlgo, aUser, aRealm, aPassword)
+	ha2 := digestHash(aAlgo, aMethod+":"+aURI)
+	cnonce := "0a4f113b"
+	response := digestHash(aAlgo, ha1+":"+aNonce+":"+aNC+":"+cnonce+":auth:"+ha2)
+
+	return `Digest username="` + aUser + `", realm="` + aRealm +
+		`", uri="` + aURI + `", algorithm=` + aAlgo +
+		`, nonce="` + aNonce + `", nc=` + aNC + `, cnonce="` + cnonce +
+		`", qop=auth, response="` + response + `"`
+} // digestHeader()
+
+// digestNonce is an internal test helper returning the nonce
+// of the first challenge of `aChallenges`.
+func digestNonce(aChallenges []string) string {
+	params, _ := parseDigest(aChallenges[0])
+
+	return params["nonce"]
+} // digestNonce()
+
+func Test_TDigestStore(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "digest.db")
+	ds := NewDigestStore(fn)
+	if nil == ds {
+		t.Fatal("NewDigestStore() = nil")
+	}
+	if err := ds.Add("user1", "realm1", "password1"); nil != err {
+		t.Fatal(err)
+	}
+	if err := ds.Add("user2", "realm1", "password2"); nil != err {
+		t.Fatal(err)
+	}
+	if err := ds.Add("user:3", "realm1", "password3"); nil == err {
+		t.Error("TDigestStore.Add() accepted a colon in the username")
+	}
+	if err := ds.Add("user3", "realm1", " "); nil == err {
+		t.Error("TDigestStore.Add() accepted an empty password")
+	}
+	if _, err := ds.Store(); nil != err {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadDigestStore(fn)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if got, want := loaded.List(), []string{"user1:realm1", "user2:realm1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TDigestStore.List() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name  string
+		user  string
+		realm string
+		pass  string
+		want  bool
+	}{
+		{" 1", "user1", "realm1", "password1", true},
+		{" 2", "user1", "realm1", "password2", false},
+		{" 3", "user1", "realm2", "password1", false},
+		{" 4", "user3", "realm1", "password3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loaded.Matches(tt.user, tt.realm, tt.pass); got != tt.want {
+				t.Errorf("TDigestStore.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// htdigest compatibility: MD5 only entries
+	if err = os.WriteFile(fn, []byte("# comment\nuser9:realm9:"+
+		digestHA1(DigestMD5, "user9", "realm9", "pw")+"\n"), 0600); nil != err {
+		t.Fatal(err)
+	}
+	if err = loaded.Load(); nil != err {
+		t.Fatal(err)
+	}
+	if _, err = loaded.Find("user9", "realm9", DigestMD5); nil != err {
+		t.Errorf("TDigestStore.Find(MD5) error = %v", err)
+	}
+	if _, err = loaded.Find("user9", "realm9", DigestSHA256); nil == err {
+		t.Error("TDigestStore.Find(SHA-256) found a missing value")
+	}
+	if !loaded.Exists("user9", "realm9") {
+		t.Error("TDigestStore.Exists(MD5 only) = false, want true")
+	}
+	if !loaded.Matches("user9", "realm9", "pw") {
+		t.Error("TDigestStore.Matches(MD5 only) = false, want true")
+	}
+	if loaded.Matches("user9", "realm9", "wrong") {
+		t.Error("TDigestStore.Matches(MD5 only, wrong) = true, want false")
+	}
+} // Test_TDigestStore()
+
+func Test_TDigestStore_foreignFile(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "pwaccess.db")
+	pwFile := "user1:$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy\n"
+	if err := os.WriteFile(fn, []byte(pwFile), 0600); nil != err {
+		t.Fatal(err)
+	}
+
+	ds := NewDigestStore(fn)
+	if err := ds.Load(); nil == err {
+		t.Error("TDigestStore.Load() accepted a password file")
+	}
+	if err := ds.Add("user2", "realm1", "password2"); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := ds.Store(); nil == err {
+		t.Error("TDigestStore.Store() overwrote a password file")
+	}
+	if got, _ := os.ReadFile(fn); pwFile != string(got) {
+		t.Errorf("password file = %q, want %q", got, pwFile)
+	}
+} // Test_TDigestStore_foreignFile()
+
+func Test_parseAuthParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   map[string]string
+		wantOK bool
+	}{
+		{" 1", "", map[string]string{}, true},
+		{" 2", `a=b, C="d e"`, map[string]string{"a": "b", "c": "d e"}, true},
+		{" 3", `a="x\"y\\z",b=1`, map[string]string{"a": `x"y\z`, "b": "1"}, true},
+		{" 4", `a="unterminated`, nil, false},
+		{" 5", `novalue`, nil, false},
+		{" 6", `a="b"c`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAuthParams(tt.text)
+			if (ok != tt.wantOK) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAuthParams() = %v, %v, want %v, %v",
+					got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+} // Test_parseAuthParams()
+
+func Test_TDigestAuth_Authenticate(t *testing.T) {
+	realm := "test realm"
+	ds := NewDigestStore("unused.db")
+	_ = ds.Add("user1", realm, "password1")
+	da := NewDigestAuth(ds, realm, time.Minute)
+	nonce := digestNonce(da.Challenges(false))
+	expired := da.newNonce(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr error
+	}{
+		{" 1", digestHeader(DigestSHA256, "user1", realm, "password1", "GET", "/a?b=c", nonce, "00000001"), "user1", nil},
+		{" 2", digestHeader(DigestMD5, "user1", realm, "password1", "GET", "/a?b=c", nonce, "00000002"), "user1", nil},
+		// replayed nonce count:
+		{" 3", digestHeader(DigestSHA256, "user1", realm, "password1", "GET", "/a?b=c", nonce, "00000002"), "", ErrInvalidCredentials},
+		{" 4", digestHeader(DigestSHA256, "user1", realm, "wrong", "GET", "/a?b=c", nonce, "00000003"), "", ErrInvalidCredentials},
+		{" 5", digestHeader(DigestSHA256, "unknown", realm, "password1", "GET", "/a?b=c", nonce, "00000004"), "", ErrInvalidCredentials},
+		{" 6", digestHeader(DigestSHA256, "user1", realm, "password1", "GET", "/other", nonce, "00000005"), "", ErrInvalidCredentials},
+		{" 7", digestHeader(DigestSHA256, "user1", "other", "password1", "GET", "/a?b=c", nonce, "00000006"), "", ErrInvalidCredentials},
+		{" 8", digestHeader(DigestSHA256, "user1", realm, "password1", "GET", "/a?b=c", "forged", "00000007"), "", ErrInvalidCredentials},
+		{" 9", digestHeader(DigestSHA256, "user1", realm, "password1", "GET", "/a?b=c", expired, "00000001"), "", ErrStaleNonce},
+		{"10", "Basic dXNlcjE6cGFzc3dvcmQx", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/a?b=c", nil)
+			req.Header.Set("Authorization", tt.header)
+			got, err := da.Authenticate(req)
+			if !errors.Is(err, tt.wantErr) || (got != tt.want) {
+				t.Errorf("TDigestAuth.Authenticate() = %q, %v, want %q, %v",
+					got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+} // Test_TDigestAuth_Authenticate()
+
+func Test_TMiddleware_digest(t *testing.T) {
+	realm := "test realm"
+	ds := NewDigestStore("unused.db")
+	_ = ds.Add("user1", realm, "password1")
+	da := NewDigestAuth(ds, realm, time.Minute, DigestSHA256)
+	u1, p1 := "username1", "password1"
+
+	var principal *TPrincipal
+	handler := NewMiddleware(
+		WithList(prepDB().add0(u1, xxHash(p1))),
+		WithDecider(TAuthNeeder{}),
+		WithDigest(da),
+		WithRealm(realm),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+	}))
+
+	// unauthenticated request: both schemes are offered
+	rec := serve(handler, "", "")
+	challenges := rec.Header().Values("WWW-Authenticate")
+	if (2 != len(challenges)) || !strings.HasPrefix(challenges[0], "Digest ") ||
+		!strings.HasPrefix(challenges[1], "Basic ") {
+		t.Fatalf("TMiddleware.Wrap() challenges = %v", challenges)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Authorization", digestHeader(DigestSHA256, "user1", realm,
+		"password1", "GET", "/", digestNonce(challenges), "00000001"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if (http.StatusOK != rec.Code) || (nil == principal) ||
+		("user1" != principal.Name) || (AuthDigest != principal.AuthMethod) {
+		t.Errorf("TMiddleware.Wrap() status = %d, principal = %v", rec.Code, principal)
+	}
+
+	// Basic still works:
+	principal = nil
+	if rec = serve(handler, u1, p1); (http.StatusOK != rec.Code) || (AuthBasic != principal.AuthMethod) {
+		t.Errorf("TMiddleware.Wrap() status = %d, principal = %v", rec.Code, principal)
+	}
+
+	// an expired nonce results in a `stale=true` challenge:
+	req.Header.Set("Authorization", digestHeader(DigestSHA256, "user1", realm,
+		"password1", "GET", "/", da.newNonce(time.Now().Add(-time.Hour)), "00000001"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("WWW-Authenticate"); !strings.Contains(got, "stale=true") {
+		t.Errorf("TMiddleware.Wrap() challenge = %q, want stale=true", got)
+	}
+} // Test_TMiddleware_digest()
+
+/* _EoF_ */
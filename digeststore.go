@@ -0,0 +1,505 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the store of HA1 values used by the HTTP
+ * Digest authentication.
+ */
+
+import (
+	"bufio"
+	"crypto/md5" // #nosec G501 – required by RFC 7616
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `DigestMD5` is the name of the (legacy) MD5 digest algorithm.
+	DigestMD5 = "MD5"
+
+	// `DigestSHA256` is the name of the SHA-256 digest algorithm.
+	DigestSHA256 = "SHA-256"
+)
+
+type (
+	// `tDigestKey` identifies an entry of the HA1 store.
+	tDigestKey struct {
+		user  string // the user's name
+		realm string // the protection space
+	}
+
+	// `tDigestHA1` holds the HA1 values of a single user and realm.
+	tDigestHA1 struct {
+		md5    string // hex encoded MD5 of "user:realm:password"
+		sha256 string // hex encoded SHA-256 of "user:realm:password"
+	}
+
+	// `TDigestStore` is a list of HA1 values (i.e. the hash of
+	// "user:realm:password") as required by the HTTP Digest
+	// authentication.
+	//
+	// The file format is one entry per line with the fields
+	// "user:realm:md5-ha1:sha256-ha1" separated by colons; empty
+	// lines and comments (starting with `#` or `;`) are skipped.
+	// A file containing any other line (e.g. a password file) is
+	// neither loaded nor overwritten.
+	//
+	// NOTE: Other than the `bcrypt` hashes of a [TPassList] the HA1
+	// values are neither peppered nor slow to compute, and each
+	// value is sufficient to authenticate as its user in its realm.
+	// Protect the file accordingly.
+	TDigestStore struct {
+		mtx      sync.RWMutex              // protect concurrent access
+		filename string                    // name of the HA1 file
+		entries  map[tDigestKey]tDigestHA1 // list of HA1 values
+	}
+)
+
+// `LoadDigestStore()` returns a new `TDigestStore` instance with the
+// contents of `aFilename`.
+//
+// Parameters:
+//   - `aFilename`: Name of the HA1 file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TDigestStore`: A new `TDigestStore` instance.
+//   - `error`: A possible error during processing the request.
+func LoadDigestStore(aFilename string) (*TDigestStore, error) {
+	ds := NewDigestStore(aFilename)
+	if nil == ds {
+		return nil, se.New(errors.New(`missing/empty file name`), 2)
+	}
+
+	return ds, ds.Load()
+} // LoadDigestStore()
+
+// `NewDigestStore()` returns a new `TDigestStore` instance.
+//
+// If `aFilename` is empty the function returns `nil`.
+//
+// Parameters:
+//   - `aFilename`: Name of the HA1 file to use by [Load] and [Store].
+//
+// Returns:
+//   - `*TDigestStore`: A new `TDigestStore` instance.
+func NewDigestStore(aFilename string) *TDigestStore {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		return nil
+	}
+
+	return &TDigestStore{
+		filename: aFilename,
+		entries:  make(map[tDigestKey]tDigestHA1, 64),
+	}
+} // NewDigestStore()
+
+// --------------------------------------------------------------------------
+// `TDigestStore` methods:
+
+// `Add()` inserts (or updates) `aUser` with `aPassword` for `aRealm`.
+//
+// Parameters:
+//   - `aUser`: The user's name.
+//   - `aRealm`: The protection space the entry is valid for.
+//   - `aPassword`: The user's password.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ds *TDigestStore) Add(aUser, aRealm, aPassword string) error {
+	if err := checkDigestName(aUser, "username"); nil != err {
+		return se.New(err, 1)
+	}
+	if err := checkDigestName(aRealm, "realm"); nil != err {
+		return se.New(err, 1)
+	}
+	if aPassword = strings.TrimSpace(aPassword); "" == aPassword {
+		return se.New(errors.New("missing/empty password"), 1)
+	}
+
+	ds.mtx.Lock()
+	ds.entries[tDigestKey{aUser, aRealm}] = tDigestHA1{
+		md5:    digestHA1(DigestMD5, aUser, aRealm, aPassword),
+		sha256: digestHA1(DigestSHA256, aUser, aRealm, aPassword),
+	}
+	ds.mtx.Unlock()
+
+	return nil
+} // Add()
+
+// `Clear()` empties the store.
+//
+// Returns:
+//   - `*TDigestStore`: The cleared store.
+func (ds *TDigestStore) Clear() *TDigestStore {
+	ds.mtx.Lock()
+	clear(ds.entries)
+	ds.mtx.Unlock()
+
+	return ds
+} // Clear()
+
+// `Exists()` returns whether there's an entry of `aUser` in `aRealm`.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//   - `aRealm`: The protection space to lookup.
+//
+// Returns:
+//   - `bool`: `true` if the entry exists, or `false` otherwise.
+func (ds *TDigestStore) Exists(aUser, aRealm string) bool {
+	ds.mtx.RLock()
+	_, ok := ds.entries[tDigestKey{aUser, aRealm}]
+	ds.mtx.RUnlock()
+
+	return ok
+} // Exists()
+
+// `Find()` returns the HA1 value of `aUser` in `aRealm` for
+// `aAlgorithm`.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//   - `aRealm`: The protection space to lookup.
+//   - `aAlgorithm`: Either [DigestMD5] or [DigestSHA256].
+//
+// Returns:
+//   - `string`: The hex encoded HA1 value.
+//   - `error`: A possible error during processing the request.
+func (ds *TDigestStore) Find(aUser, aRealm, aAlgorithm string) (string, error) {
+	if nil == ds {
+		return "", se.New(errors.New("missing HA1 store"), 1)
+	}
+
+	ds.mtx.RLock()
+	entry, ok := ds.entries[tDigestKey{aUser, aRealm}]
+	ds.mtx.RUnlock()
+	if !ok {
+		return "", se.New(fmt.Errorf("user '%s' not found in realm '%s'", aUser, aRealm), 2)
+	}
+
+	result := entry.sha256
+	if DigestMD5 == aAlgorithm {
+		result = entry.md5
+	}
+	if "" == result {
+		return "", se.New(fmt.Errorf("no %s value of user '%s'", aAlgorithm, aUser), 2)
+	}
+
+	return result, nil
+} // Find()
+
+// `Len()` returns the number of entries in the store.
+//
+// Returns:
+//   - `int`: The number of entries.
+func (ds *TDigestStore) Len() int {
+	ds.mtx.RLock()
+	defer ds.mtx.RUnlock()
+
+	return len(ds.entries)
+} // Len()
+
+// `List()` returns a sorted list of all entries as "user:realm".
+//
+// Returns:
+//   - `[]string`: The list of users and their realms.
+func (ds *TDigestStore) List() []string {
+	ds.mtx.RLock()
+	result := make([]string, 0, len(ds.entries))
+	for key := range ds.entries {
+		result = append(result, key.user+":"+key.realm)
+	}
+	ds.mtx.RUnlock()
+	slices.Sort(result)
+
+	return result
+} // List()
+
+// `Load()` reads the HA1 file replacing the store's current contents.
+//
+// If the file contains a line that's not an HA1 entry (e.g. because
+// it's a password file) an error is returned and the store's contents
+// remain unchanged.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ds *TDigestStore) Load() error {
+	if "" == ds.filename {
+		return se.New(errors.New("missing/empty filename"), 1)
+	}
+
+	file, err := os.Open(ds.filename)
+	if nil != err {
+		return se.New(err, 2)
+	}
+	defer file.Close()
+
+	entries, err := ds.read(file)
+	if nil != err {
+		return err // already wrapped
+	}
+
+	ds.mtx.Lock()
+	ds.entries = entries
+	ds.mtx.Unlock()
+
+	return nil
+} // Load()
+
+// `Matches()` checks whether `aPassword` of `aUser` in `aRealm`
+// matches the stored HA1 value.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//   - `aRealm`: The protection space to lookup.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `bool`: `true` if the password matches, or `false` otherwise.
+func (ds *TDigestStore) Matches(aUser, aRealm, aPassword string) bool {
+	algo := DigestSHA256
+	ha1, err := ds.Find(aUser, aRealm, algo)
+	if nil != err {
+		// Entries imported from `htdigest` files have MD5 values only.
+		algo = DigestMD5
+		if ha1, err = ds.Find(aUser, aRealm, algo); nil != err {
+			return false
+		}
+	}
+	want := digestHA1(algo, aUser, aRealm, strings.TrimSpace(aPassword))
+
+	return 1 == subtle.ConstantTimeCompare([]byte(ha1), []byte(want))
+} // Matches()
+
+// `read()` parses the HA1 entries of `aFile`.
+//
+// Parameters:
+//   - `aFile`: The opened HA1 file.
+//
+// Returns:
+//   - `map[tDigestKey]tDigestHA1`: The file's entries.
+//   - `error`: A possible error during processing the request.
+func (ds *TDigestStore) read(aFile *os.File) (map[tDigestKey]tDigestHA1, error) {
+	entries := make(map[tDigestKey]tDigestHA1, 64)
+	scanner := bufio.NewScanner(aFile)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if (0 == len(line)) || (';' == line[0]) || ('#' == line[0]) {
+			// Skip blank and comment lines
+			continue
+		}
+
+		key, entry, ok := parseDigestLine(line)
+		if !ok {
+			return nil, se.New(fmt.Errorf("%q line %d: not an HA1 entry", ds.filename, lineNo), 2)
+		}
+		entries[key] = entry
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, se.New(err, 2)
+	}
+
+	return entries, nil
+} // read()
+
+// `Remove()` deletes the entry of `aUser` in `aRealm`.
+//
+// Parameters:
+//   - `aUser`: The username to remove.
+//   - `aRealm`: The protection space of the entry.
+//
+// Returns:
+//   - `*TDigestStore`: The updated store.
+func (ds *TDigestStore) Remove(aUser, aRealm string) *TDigestStore {
+	ds.mtx.Lock()
+	delete(ds.entries, tDigestKey{aUser, aRealm})
+	ds.mtx.Unlock()
+
+	return ds
+} // Remove()
+
+// `Store()` writes the store's contents to the HA1 file.
+//
+// An existing file containing a line that's not an HA1 entry (e.g.
+// because it's a password file) is left untouched and an error is
+// returned instead.
+//
+// Returns:
+//   - `int`: The number of bytes written.
+//   - `error`: A possible error during processing the request.
+func (ds *TDigestStore) Store() (int, error) {
+	if "" == ds.filename {
+		return 0, se.New(errors.New("missing/empty filename"), 1)
+	}
+	if file, err := os.Open(ds.filename); nil == err {
+		_, err = ds.read(file)
+		_ = file.Close()
+		if nil != err {
+			return 0, err // already wrapped
+		}
+	}
+	s := []byte(ds.String())
+
+	file, err := os.OpenFile(ds.filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G302
+	if nil != err {
+		return 0, se.New(err, 2)
+	}
+	defer file.Close()
+
+	return file.Write(s)
+} // Store()
+
+// `String()` returns the store as a single, LF-separated string.
+//
+// Returns:
+//   - `string`: A stringified representation of the store.
+func (ds *TDigestStore) String() string {
+	ds.mtx.RLock()
+	list := make([]string, 0, len(ds.entries))
+	for key, entry := range ds.entries {
+		list = append(list, key.user+":"+key.realm+":"+entry.md5+":"+entry.sha256)
+	}
+	ds.mtx.RUnlock()
+	if 0 == len(list) {
+		return ""
+	}
+	slices.Sort(list)
+
+	return strings.Join(list, "\n") + "\n"
+} // String()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `checkDigestName()` checks whether `aName` can be stored in an
+// HA1 file.
+//
+// Parameters:
+//   - `aName`: The username or realm to check.
+//   - `aWhat`: The kind of name for error messages.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func checkDigestName(aName, aWhat string) error {
+	if "" == strings.TrimSpace(aName) {
+		return fmt.Errorf("missing/empty %s", aWhat)
+	}
+	if aName != strings.TrimSpace(aName) {
+		return fmt.Errorf("%s '%s' has leading/trailing blanks", aWhat, aName)
+	}
+	if strings.ContainsAny(aName, ":\r\n") {
+		return fmt.Errorf("%s '%s' contains invalid characters", aWhat, aName)
+	}
+
+	return nil
+} // checkDigestName()
+
+// `digestHash()` returns the hex encoded hash of `aData` using
+// `aAlgorithm`.
+//
+// Parameters:
+//   - `aAlgorithm`: Either [DigestMD5] or [DigestSHA256].
+//   - `aData`: The data to hash.
+//
+// Returns:
+//   - `string`: The hex encoded hash.
+func digestHash(aAlgorithm, aData string) string {
+	var h hash.Hash
+	if DigestMD5 == aAlgorithm {
+		h = md5.New() // #nosec G401
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(aData))
+
+	return hex.EncodeToString(h.Sum(nil))
+} // digestHash()
+
+// `digestHA1()` returns the HA1 value of the given credentials.
+//
+// Parameters:
+//   - `aAlgorithm`: Either [DigestMD5] or [DigestSHA256].
+//   - `aUser`: The user's name.
+//   - `aRealm`: The protection space.
+//   - `aPassword`: The user's password.
+//
+// Returns:
+//   - `string`: The hex encoded HA1 value.
+func digestHA1(aAlgorithm, aUser, aRealm, aPassword string) string {
+	return digestHash(aAlgorithm, aUser+":"+aRealm+":"+aPassword)
+} // digestHA1()
+
+// `isHexHash()` checks whether `aValue` is a hex encoded hash of
+// `aLen` bytes.
+//
+// Parameters:
+//   - `aValue`: The value to check.
+//   - `aLen`: The expected hash length in bytes.
+//
+// Returns:
+//   - `bool`: `true` if `aValue` is a valid hash, or `false` otherwise.
+func isHexHash(aValue string, aLen int) bool {
+	if (2 * aLen) != len(aValue) {
+		return false
+	}
+	_, err := hex.DecodeString(aValue)
+
+	return nil == err
+} // isHexHash()
+
+// `parseDigestLine()` parses a single line of an HA1 file.
+//
+// Parameters:
+//   - `aLine`: The (non-empty, non-comment) line to parse.
+//
+// Returns:
+//   - `tDigestKey`: The entry's user and realm.
+//   - `tDigestHA1`: The entry's HA1 values.
+//   - `bool`: `true` if the line is a valid HA1 entry.
+func parseDigestLine(aLine string) (tDigestKey, tDigestHA1, bool) {
+	var (
+		key   tDigestKey
+		entry tDigestHA1
+	)
+	parts := strings.Split(aLine, ":")
+	if (3 > len(parts)) || (4 < len(parts)) {
+		return key, entry, false
+	}
+
+	key = tDigestKey{strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])}
+	entry.md5 = strings.TrimSpace(parts[2])
+	if 4 == len(parts) {
+		entry.sha256 = strings.TrimSpace(parts[3])
+	}
+	switch {
+	case ("" == key.user) || ("" == key.realm):
+		return key, entry, false
+	case ("" == entry.md5) && ("" == entry.sha256):
+		return key, entry, false
+	case ("" != entry.md5) && !isHexHash(entry.md5, md5.Size):
+		return key, entry, false
+	case ("" != entry.sha256) && !isHexHash(entry.sha256, sha256.Size):
+		return key, entry, false
+	}
+
+	return key, entry, true
+} // parseDigestLine()
+
+/* _EoF_ */
@@ -0,0 +1,142 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides an endpoint answering the authentication
+ * sub-requests of reverse proxies like nginx or Traefik.
+ */
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `HeaderRemoteUser` is the response header of [TMiddleware.ForwardAuth]
+	// carrying the authenticated user's name.
+	HeaderRemoteUser = "X-Remote-User"
+
+	// `HeaderRemoteGroups` is the response header of [TMiddleware.ForwardAuth]
+	// carrying the authenticated user's comma separated roles.
+	HeaderRemoteGroups = "X-Remote-Groups"
+
+	// `ForwardNginx` selects the `X-Original-*` headers sent by an
+	// nginx `auth_request` configuration.
+	ForwardNginx = "nginx"
+
+	// `ForwardTraefik` selects the `X-Forwarded-*` headers sent by
+	// Traefik's `ForwardAuth` middleware.
+	ForwardTraefik = "traefik"
+)
+
+var (
+	// The headers describing the original method, URI, and host
+	// sent by the supported proxies.
+	fwdHeaders = map[string][3]string{
+		ForwardNginx:   {"X-Original-Method", "X-Original-URI", "X-Original-Host"},
+		ForwardTraefik: {"X-Forwarded-Method", "X-Forwarded-Uri", "X-Forwarded-Host"},
+	}
+)
+
+// `ForwardAuth()` returns a handler answering the authentication
+// sub-requests sent by reverse proxies like nginx (`auth_request`)
+// or Traefik (`ForwardAuth`).
+//
+// The original request is reconstructed from the headers sent by
+// the proxy type `aProxy`: for [ForwardNginx] these are the
+// `X-Original-Method`, `X-Original-URI`, and `X-Original-Host`
+// headers, for [ForwardTraefik] the `X-Forwarded-Method`,
+// `X-Forwarded-Uri`, and `X-Forwarded-Host` headers. The headers of
+// the other proxy type are ignored since a client could send them
+// along with its request (which Traefik passes on). The credentials
+// are taken from the sub-request's own `Authorization` header (which
+// the proxies pass on from the client's request).
+//
+// NOTE: The selected headers must be set by the proxy for each
+// sub-request, overwriting any values sent by the client; otherwise
+// a client could make the decider see another path.
+//
+// If the configured `IAuthDecider` decides that the original request
+// doesn't need authentication the handler answers "200 OK" at once.
+// Otherwise the credentials are checked: on success the response is
+// "200 OK" with the user's name in the `X-Remote-User` header and its
+// roles (see [WithRoles]) in the `X-Remote-Groups` header; on failure
+// the response is the same as sent by [TMiddleware.Wrap] (i.e. "401
+// Unauthorised" with the challenge(s) in `WWW-Authenticate`).
+//
+// NOTE: The client's address seen by the handler is the proxy's one;
+// to use a [TLockout] configure the proxy to send `X-Forwarded-For`
+// and add it to the lockout's trusted proxies.
+//
+// Parameters:
+//   - `aProxy`: Either [ForwardNginx] or [ForwardTraefik].
+//
+// Returns:
+//   - `http.Handler`: The forward-auth handler.
+func (mw *TMiddleware) ForwardAuth(aProxy string) http.Handler {
+	decider := mw.setup("ForwardAuth")
+	headers, ok := fwdHeaders[strings.ToLower(strings.TrimSpace(aProxy))]
+	if !ok {
+		mw.logger.Printf("passlist.ForwardAuth(): unknown proxy type %q\nAUTHENTICATING ALL REQUESTS!\n", aProxy)
+		decider = TAuthNeeder{}
+	}
+
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		original := forwardedRequest(aRequest, headers)
+		if (nil == decider) || !decider.NeedAuthentication(original) {
+			aWriter.WriteHeader(http.StatusOK)
+			return
+		}
+		if original = mw.authenticate(aWriter, original, false); nil == original {
+			return // response already sent
+		}
+
+		if p := PrincipalFromContext(original.Context()); nil != p {
+			aWriter.Header().Set(HeaderRemoteUser, p.Name)
+			if 0 < len(p.Roles) {
+				aWriter.Header().Set(HeaderRemoteGroups, strings.Join(p.Roles, ","))
+			}
+		}
+		aWriter.WriteHeader(http.StatusOK)
+	})
+} // ForwardAuth()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `forwardedRequest()` returns a copy of the sub-request `aRequest`
+// describing the original request as reported by the proxy.
+//
+// Parameters:
+//   - `aRequest`: The sub-request received from the proxy.
+//   - `aHeaders`: The names of the method, URI, and host headers.
+//
+// Returns:
+//   - `*http.Request`: The reconstructed original request.
+func forwardedRequest(aRequest *http.Request, aHeaders [3]string) *http.Request {
+	result := aRequest.Clone(aRequest.Context())
+
+	if method := strings.TrimSpace(aRequest.Header.Get(aHeaders[0])); "" != method {
+		result.Method = strings.ToUpper(method)
+	}
+	if uri := strings.TrimSpace(aRequest.Header.Get(aHeaders[1])); "" != uri {
+		if u, err := url.ParseRequestURI(uri); nil == err {
+			result.URL, result.RequestURI = u, uri
+		}
+	}
+	if host := strings.TrimSpace(aRequest.Header.Get(aHeaders[2])); "" != host {
+		result.Host = host
+	}
+
+	return result
+} // forwardedRequest()
+
+/* _EoF_ */
@@ -0,0 +1,140 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tPrivateDecider is an internal test helper requiring authentication
+// for all paths starting with "/private".
+type tPrivateDecider struct{}
+
+func (tPrivateDecider) NeedAuthentication(aRequest *http.Request) bool {
+	return strings.HasPrefix(aRequest.URL.Path, "/private")
+}
+
+func Test_TMiddleware_ForwardAuth(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	mw := NewMiddleware(
+		WithList(ul),
+		WithDecider(tPrivateDecider{}),
+		WithRoles(func(aUser string) []string {
+			return []string{"admin", "staff"}
+		}),
+		WithLogger(quietLogger),
+	)
+	nginx, traefik := mw.ForwardAuth(ForwardNginx), mw.ForwardAuth(ForwardTraefik)
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		header     string
+		uri        string
+		user       string
+		pass       string
+		want       int
+		wantUser   string
+		wantGroups string
+	}{
+		{" 1", nginx, "X-Original-URI", "/public/page", "", "", http.StatusOK, "", ""},
+		{" 2", nginx, "X-Original-URI", "/private/page?a=b", "", "", http.StatusUnauthorized, "", ""},
+		{" 3", nginx, "X-Original-URI", "/private/page", u1, "wrong", http.StatusUnauthorized, "", ""},
+		{" 4", nginx, "X-Original-URI", "/private/page", u1, p1, http.StatusOK, u1, "admin,staff"},
+		{" 5", traefik, "X-Forwarded-Uri", "/private/page", u1, p1, http.StatusOK, u1, "admin,staff"},
+		{" 6", traefik, "X-Forwarded-Uri", "/public/page", "", "", http.StatusOK, "", ""},
+		// the sub-request's own path doesn't matter:
+		{" 7", nginx, "", "", "", "", http.StatusOK, "", ""},
+		// the other proxy's headers are ignored:
+		{" 8", traefik, "X-Original-URI", "/private/page", "", "", http.StatusOK, "", ""},
+		{" 9", nginx, "X-Forwarded-Uri", "/private/page", "", "", http.StatusOK, "", ""},
+		// unknown proxy types need authentication for all requests:
+		{"10", mw.ForwardAuth("apache"), "X-Original-URI", "/public/page", "", "", http.StatusUnauthorized, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://auth.example.com/auth", nil)
+			if "" != tt.header {
+				req.Header.Set(tt.header, tt.uri)
+			}
+			if "" != tt.user {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("ForwardAuth() status = %d, want %d", rec.Code, tt.want)
+			}
+			if got := rec.Header().Get(HeaderRemoteUser); got != tt.wantUser {
+				t.Errorf("ForwardAuth() user = %q, want %q", got, tt.wantUser)
+			}
+			if got := rec.Header().Get(HeaderRemoteGroups); got != tt.wantGroups {
+				t.Errorf("ForwardAuth() groups = %q, want %q", got, tt.wantGroups)
+			}
+			if (http.StatusUnauthorized == tt.want) &&
+				("" == rec.Header().Get("WWW-Authenticate")) {
+				t.Error("ForwardAuth() missing WWW-Authenticate header")
+			}
+		})
+	}
+} // Test_TMiddleware_ForwardAuth()
+
+func Test_TMiddleware_ForwardAuth_spoofed(t *testing.T) {
+	ul := prepDB().add0("username1", xxHash("password1"))
+	mw := NewMiddleware(
+		WithList(ul),
+		WithDecider(tPrivateDecider{}),
+		WithLogger(quietLogger),
+	)
+
+	tests := []struct {
+		name   string
+		proxy  string
+		client string // header sent by the client
+		real   string // header set by the proxy
+	}{
+		{" 1", ForwardTraefik, "X-Original-URI", "X-Forwarded-Uri"},
+		{" 2", ForwardNginx, "X-Forwarded-Uri", "X-Original-URI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://auth.example.com/auth", nil)
+			req.Header.Set(tt.client, "/public/page")
+			req.Header.Set(tt.real, "/private/page")
+			rec := httptest.NewRecorder()
+			mw.ForwardAuth(tt.proxy).ServeHTTP(rec, req)
+
+			if http.StatusUnauthorized != rec.Code {
+				t.Errorf("ForwardAuth(%s) status = %d, want %d",
+					tt.proxy, rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+} // Test_TMiddleware_ForwardAuth_spoofed()
+
+func Test_forwardedRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://auth.example.com/auth", nil)
+	req.Header.Set("X-Forwarded-Method", "post")
+	req.Header.Set("X-Forwarded-Uri", "/some/path?x=1")
+	req.Header.Set("X-Forwarded-Host", "www.example.com")
+
+	got := forwardedRequest(req, fwdHeaders[ForwardTraefik])
+	if ("POST" != got.Method) || ("/some/path" != got.URL.Path) ||
+		("x=1" != got.URL.RawQuery) || ("www.example.com" != got.Host) {
+		t.Errorf("forwardedRequest() = %s %s %s", got.Method, got.Host, got.URL)
+	}
+	if "/auth" != req.URL.Path {
+		t.Errorf("forwardedRequest() modified the sub-request: %s", req.URL)
+	}
+} // Test_forwardedRequest()
+
+/* _EoF_ */
@@ -0,0 +1,201 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the authentication by an identity header set
+ * by a trusted upstream proxy (e.g. an SSO-enabled ingress).
+ */
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `HeaderForwardedUser` is the default identity header of
+	// a [THeaderAuth].
+	HeaderForwardedUser = "X-Forwarded-User"
+
+	// Max. length of a username sent in the identity header.
+	maxHeaderUser = 256
+)
+
+var (
+	// `ErrUnknownUser` is returned (and passed to [THooks.OnFailure])
+	// if a trusted proxy reported a user missing in the user list.
+	ErrUnknownUser = errors.New("unknown user")
+)
+
+type (
+	// `THeaderAuth` authenticates requests by an identity header set
+	// by a trusted upstream proxy.
+	//
+	// The header is accepted only if the request's remote address
+	// belongs to one of the trusted proxies; requests from anywhere
+	// else (or without the header) fall back to the Basic
+	// authentication by the user list (if any).
+	THeaderAuth struct {
+		header  string           // name of the identity header
+		proxies *TTrustedProxies // proxies allowed to send the header
+		list    IUserList        // optional user list
+		exists  bool             // require the users to exist in `list`
+	}
+)
+
+// `NewHeaderAuth()` returns a new identity header authenticator.
+//
+// Parameters:
+//   - `aHeader`: The name of the identity header (default: [HeaderForwardedUser]).
+//   - `aProxies`: The proxies trusted to send the header.
+//   - `aList`: The user list for the Basic fallback (may be `nil`).
+//
+// Returns:
+//   - `*THeaderAuth`: The new authenticator.
+func NewHeaderAuth(aHeader string, aProxies *TTrustedProxies, aList IUserList) *THeaderAuth {
+	if aHeader = strings.TrimSpace(aHeader); "" == aHeader {
+		aHeader = HeaderForwardedUser
+	}
+
+	return &THeaderAuth{
+		header:  http.CanonicalHeaderKey(aHeader),
+		proxies: aProxies,
+		list:    aList,
+	}
+} // NewHeaderAuth()
+
+// --------------------------------------------------------------------------
+// `THeaderAuth` methods:
+
+// `AuthenticateContext()` checks `aRequest` for a trusted identity
+// header or (as a fallback) Basic credentials, returning `nil` for
+// successful authentication, or an `error` otherwise.
+//
+// On success the username is stored in the `aRequest.URL.User`
+// structure like [TPassList.AuthenticateContext] does.
+//
+// Parameters:
+//   - `aCtx`: The context controlling a password verification.
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ha *THeaderAuth) AuthenticateContext(aCtx context.Context, aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	user, err := ha.User(aRequest)
+	if nil != err {
+		return err
+	}
+	if "" != user {
+		aRequest.URL.User = url.User(user)
+		return nil
+	}
+	if nil == ha.list {
+		return ErrInvalidCredentials
+	}
+
+	return ha.list.AuthenticateContext(aCtx, aRequest)
+} // AuthenticateContext()
+
+// `IsAuthenticated()` checks `aRequest` for a trusted identity header
+// or (as a fallback) Basic credentials, returning `nil` for successful
+// authentication, or an `error` otherwise.
+//
+// See [THeaderAuth.AuthenticateContext] for details.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ha *THeaderAuth) IsAuthenticated(aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return ha.AuthenticateContext(aRequest.Context(), aRequest)
+} // IsAuthenticated()
+
+// `SetRequireUser()` decides whether the users reported by the proxy
+// must exist in the user list.
+//
+// Parameters:
+//   - `aRequire`: Whether to check the users' existence.
+//
+// Returns:
+//   - `*THeaderAuth`: The authenticator itself, allowing method chaining.
+func (ha *THeaderAuth) SetRequireUser(aRequire bool) *THeaderAuth {
+	ha.exists = aRequire
+
+	return ha
+} // SetRequireUser()
+
+// `strip()` removes the identity header from `aRequest` unless it
+// was sent by a trusted proxy, so later handlers can't be fooled by
+// a forged header.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+func (ha *THeaderAuth) strip(aRequest *http.Request) {
+	if !ha.trusted(aRequest) {
+		aRequest.Header.Del(ha.header)
+	}
+} // strip()
+
+// `trusted()` returns whether `aRequest` was sent by a trusted proxy.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `bool`: `true` if the remote address is a trusted proxy.
+func (ha *THeaderAuth) trusted(aRequest *http.Request) bool {
+	return ha.proxies.Contains(remoteAddr(aRequest))
+} // trusted()
+
+// `User()` returns the user reported by a trusted proxy.
+//
+// If the request wasn't sent by a trusted proxy, or doesn't carry
+// the identity header, the method returns an empty string and no
+// error. If the user's existence is required (see
+// [THeaderAuth.SetRequireUser]) but the user isn't in the user
+// list, the method returns [ErrUnknownUser].
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `string`: The name of the user reported by the proxy.
+//   - `error`: A possible error during processing the request.
+func (ha *THeaderAuth) User(aRequest *http.Request) (string, error) {
+	if (nil == aRequest) || !ha.trusted(aRequest) {
+		return "", nil
+	}
+	user := strings.TrimSpace(aRequest.Header.Get(ha.header))
+	if ("" == user) || (maxHeaderUser < len(user)) ||
+		(0 <= strings.IndexFunc(user, unicode.IsControl)) {
+		return "", nil
+	}
+	if ha.exists && ((nil == ha.list) || !ha.list.Exists(user)) {
+		return "", ErrUnknownUser
+	}
+
+	return user, nil
+} // User()
+
+/* _EoF_ */
@@ -0,0 +1,159 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// headerRequest is an internal test helper returning a request
+// sent from `aRemote` with the identity header set to `aUser`.
+func headerRequest(aRemote, aUser string) *http.Request {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.RemoteAddr = aRemote
+	if "" != aUser {
+		req.Header.Set(HeaderForwardedUser, aUser)
+	}
+
+	return req
+} // headerRequest()
+
+func Test_THeaderAuth_User(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	proxies, _ := NewTrustedProxies("10.0.0.0/8")
+	ha := NewHeaderAuth("", proxies, ul)
+	strict := NewHeaderAuth("x-forwarded-user", proxies, ul).SetRequireUser(true)
+
+	tests := []struct {
+		name    string
+		auth    *THeaderAuth
+		remote  string
+		user    string
+		want    string
+		wantErr error
+	}{
+		{" 1", ha, "10.1.2.3:1234", u1, u1, nil},
+		{" 2", ha, "10.1.2.3:1234", "somebody", "somebody", nil},
+		{" 3", ha, "192.0.2.1:1234", u1, "", nil},
+		{" 4", ha, "10.1.2.3:1234", "", "", nil},
+		{" 5", ha, "10.1.2.3:1234", "bad\x01user", "", nil},
+		{" 6", ha, "10.1.2.3:1234", strings.Repeat("x", maxHeaderUser+1), "", nil},
+		{" 7", strict, "10.1.2.3:1234", u1, u1, nil},
+		{" 8", strict, "10.1.2.3:1234", "somebody", "", ErrUnknownUser},
+		{" 9", strict, "192.0.2.1:1234", "somebody", "", nil},
+		{"10", NewHeaderAuth("", nil, ul), "10.1.2.3:1234", u1, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.auth.User(headerRequest(tt.remote, tt.user))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("THeaderAuth.User() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("THeaderAuth.User() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+} // Test_THeaderAuth_User()
+
+func Test_THeaderAuth_IsAuthenticated(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	proxies, _ := NewTrustedProxies("10.0.0.0/8")
+	ha := NewHeaderAuth("", proxies, ul)
+
+	tests := []struct {
+		name    string
+		remote  string
+		user    string
+		pass    string
+		wantErr bool
+	}{
+		{" 1", "10.1.2.3:1234", "somebody", "", false},
+		{" 2", "192.0.2.1:1234", "somebody", "", true},
+		{" 3", "192.0.2.1:1234", "", p1, false},
+		{" 4", "192.0.2.1:1234", "", "wrong", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := headerRequest(tt.remote, tt.user)
+			if "" != tt.pass {
+				req.SetBasicAuth(u1, tt.pass)
+			}
+			if err := ha.IsAuthenticated(req); (nil != err) != tt.wantErr {
+				t.Errorf("THeaderAuth.IsAuthenticated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+} // Test_THeaderAuth_IsAuthenticated()
+
+func Test_TMiddleware_headerAuth(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	proxies, _ := NewTrustedProxies("10.0.0.0/8")
+
+	var (
+		principal *TPrincipal
+		forwarded string
+	)
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithHeaderAuth(NewHeaderAuth("", proxies, ul).SetRequireUser(true)),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+		forwarded = aRequest.Header.Get(HeaderForwardedUser)
+		aWriter.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		remote     string
+		user       string
+		basic      bool
+		want       int
+		wantMethod string
+	}{
+		{" 1", "10.1.2.3:1234", u1, false, http.StatusOK, AuthHeader},
+		{" 2", "10.1.2.3:1234", "somebody", false, http.StatusForbidden, ""},
+		{" 3", "192.0.2.1:1234", u1, false, http.StatusUnauthorized, ""},
+		{" 4", "192.0.2.1:1234", u1, true, http.StatusOK, AuthBasic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, forwarded = nil, ""
+			req := headerRequest(tt.remote, tt.user)
+			if tt.basic {
+				req.SetBasicAuth(u1, p1)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, tt.want)
+			}
+			if http.StatusOK != tt.want {
+				return
+			}
+			if (nil == principal) || (u1 != principal.Name) || (tt.wantMethod != principal.AuthMethod) {
+				t.Errorf("TMiddleware.Wrap() principal = %+v, want %q/%q",
+					principal, u1, tt.wantMethod)
+			}
+			if (AuthBasic == tt.wantMethod) && ("" != forwarded) {
+				t.Errorf("TMiddleware.Wrap() passed on untrusted header %q", forwarded)
+			}
+		})
+	}
+} // Test_TMiddleware_headerAuth()
+
+/* _EoF_ */
@@ -0,0 +1,918 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides a user/password list stored in an indexed
+ * file which is accessed without loading all its contents.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	se "github.com/mwat56/sourceerror"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+// The indexed file starts with a header of `idxHdrSize` bytes:
+//
+//	magic    [8]byte  // "PLIDX001"
+//	sorted   uint64   // number of sorted records
+//	total    uint64   // number of all records
+//	active   uint64   // number of non-removed records
+//
+// followed by records of `idxRecSize` bytes each:
+//
+//	flag     byte     // `idxActive` or `idxRemoved`
+//	user     [64]byte // NUL-padded username
+//	hash     [190]byte // NUL-padded password hash
+//	LF       byte     // for the human reader
+//
+// The first `sorted` records are ordered by username and searched
+// by bisection, the remaining ones are appended in arbitrary order
+// and searched sequentially until the next [TIndexedList.Compact].
+const (
+	idxMagic    = "PLIDX001"
+	idxHdrSize  = 64
+	idxRecSize  = 256
+	idxUserLen  = 64
+	idxHashLen  = idxRecSize - idxUserLen - 2
+	idxActive   = '+'
+	idxRemoved  = '-'
+	idxMaxExtra = 1024 // max. number of unsorted records
+)
+
+type (
+	// `TIndexedList` is a user/password list kept in an indexed file.
+	//
+	// Other than [TPassList] it doesn't load the whole file into memory
+	// but looks up the users by bisecting the file's sorted records.
+	// Changing or removing a single user only touches that user's
+	// record, and new users are appended to the file until it gets
+	// compacted.
+	//
+	// The list is safe for concurrent use.
+	TIndexedList struct {
+		tVerifier              // password verification settings
+		mtx       sync.RWMutex // protect concurrent access
+		file      *os.File     // the opened index file
+		filename  string       // name of the index file
+		data      []byte       // memory-mapped file contents
+		mapped    bool         // whether to memory-map the file
+		sorted    int          // number of sorted records
+		total     int          // number of all records
+		active    int          // number of non-removed records
+	}
+)
+
+// `OpenIndexed()` opens the indexed password file `aFilename`
+// returning a `TIndexedList` instance and a possible error condition.
+//
+// If the file doesn't exist yet it is created.
+//
+// If `aMapped` is `true` the file is memory-mapped for reading
+// (on platforms supporting that).
+//
+// Parameters:
+//   - `aFilename`: Name of the indexed password file to use.
+//   - `aMapped`: Whether to memory-map the file.
+//
+// Returns:
+//   - `*TIndexedList`: The new list instance.
+//   - `error`: A possible error during processing the request.
+func OpenIndexed(aFilename string, aMapped bool) (*TIndexedList, error) {
+	if aFilename = strings.TrimSpace(aFilename); "" == aFilename {
+		return nil, se.New(errors.New(`missing/empty file name`), 1)
+	}
+
+	il := &TIndexedList{
+		filename: aFilename,
+		mapped:   aMapped,
+	}
+	if err := il.open(); nil != err {
+		return nil, err // already wrapped
+	}
+
+	return il, nil
+} // OpenIndexed()
+
+// --------------------------------------------------------------------------
+// `TIndexedList` methods:
+
+// `Add()` inserts `aUser` with `aPassword` into the list or updates
+// the password of an already existing user.
+//
+// If either `aUser` or `aPassword` is empty the method returns an error.
+//
+// Before storing `aPassword` it gets peppered and hashed.
+//
+// Parameters:
+//   - `aUser`: The user's name to use.
+//   - `aPassword`: The user's password to store.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Add(aUser, aPassword string) error {
+	if aUser = strings.TrimSpace(aUser); "" == aUser {
+		return se.New(errors.New("missing/empty username"), 1)
+	}
+	if aPassword = strings.TrimSpace(aPassword); "" == aPassword {
+		return se.New(errors.New("missing/empty password"), 1)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(aPassword+pwPepper), pwCost)
+	if nil != err {
+		return se.New(err, 2)
+	}
+
+	return il.Put(aUser, string(hash))
+} // Add()
+
+// `AuthenticateContext()` checks `aRequest` for authentication data,
+// returning `nil` for successful authentication, or an `error` otherwise.
+//
+// See [TPassList.AuthenticateContext] for details.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) AuthenticateContext(aCtx context.Context, aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return il.tVerifier.authenticate(aCtx, aRequest, "Authorization", il.Find)
+} // AuthenticateContext()
+
+// `AuthenticateProxyContext()` checks the `Proxy-Authorization` header
+// of `aRequest`, returning `nil` for successful authentication, or an
+// `error` otherwise.
+//
+// See [TPassList.AuthenticateProxyContext] for details.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aRequest` The HTTP request received by a proxy.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) AuthenticateProxyContext(aCtx context.Context, aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return il.tVerifier.authenticate(aCtx, aRequest, "Proxy-Authorization", il.Find)
+} // AuthenticateProxyContext()
+
+// `Close()` flushes and closes the index file.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Close() error {
+	il.mtx.Lock()
+	defer il.mtx.Unlock()
+
+	return il.close()
+} // Close()
+
+// `close()` unmaps and closes the index file.
+//
+// NOTE: The caller must hold the write lock.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) close() error {
+	if nil != il.data {
+		_ = munmapFile(il.data)
+		il.data = nil
+	}
+	if nil == il.file {
+		return nil
+	}
+
+	err := il.file.Sync()
+	if cErr := il.file.Close(); nil == err {
+		err = cErr
+	}
+	il.file = nil
+	if nil != err {
+		return se.New(err, 1)
+	}
+
+	return nil
+} // close()
+
+// `Compact()` rewrites the index file with all active records sorted
+// and all removed records dropped.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Compact() error {
+	il.mtx.Lock()
+	defer il.mtx.Unlock()
+
+	users, err := il.collect()
+	if nil != err {
+		return err // already wrapped
+	}
+
+	return il.rewrite(users)
+} // Compact()
+
+// `collect()` returns all active user/hash pairs of the index file.
+//
+// NOTE: The caller must hold (at least) the read lock.
+//
+// Returns:
+//   - `tUserMap`: The list of active users.
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) collect() (tUserMap, error) {
+	result := make(tUserMap, il.active)
+	for idx := 0; idx < il.total; idx++ {
+		rec, err := il.record(idx)
+		if nil != err {
+			return nil, err // already wrapped
+		}
+		if idxActive == rec[0] {
+			result[recUser(rec)] = recHash(rec)
+		}
+	}
+
+	return result, nil
+} // collect()
+
+// `Exists()` returns `true` if `aUser` exists in the list,
+// or `false` if not found.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//
+// Returns:
+//   - `bool`: `true` if the user as was found, or `false` otherwise.
+func (il *TIndexedList) Exists(aUser string) bool {
+	_, err := il.Find(aUser)
+
+	return (nil == err)
+} // Exists()
+
+// `Find()` returns the hashed password of `aUser` and `nil`,
+// or an error if not found.
+//
+// If `aUser` is empty the method returns an error.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//
+// Returns:
+//   - `string`: The user's password hash.
+//   - `error`: `nil` if the user as was found, or an error otherwise.
+func (il *TIndexedList) Find(aUser string) (string, error) {
+	if aUser = strings.TrimSpace(aUser); "" == aUser {
+		return "", se.New(errors.New("missing/empty username"), 2)
+	}
+
+	il.mtx.RLock()
+	defer il.mtx.RUnlock()
+
+	idx, err := il.search(aUser)
+	if nil != err {
+		return "", err // already wrapped
+	}
+	if 0 > idx {
+		return "", se.New(errors.New("unknown user"), 2)
+	}
+	rec, err := il.record(idx)
+	if nil != err {
+		return "", err // already wrapped
+	}
+	if idxActive != rec[0] {
+		return "", se.New(errors.New("unknown user"), 2)
+	}
+
+	return recHash(rec), nil
+} // Find()
+
+// `Import()` adds all users of `aList` to the index file replacing
+// the hashes of already existing users.
+//
+// Other than calling [TIndexedList.Put] for each user this method
+// rewrites the index file just once.
+//
+// Parameters:
+//   - `aList`: The password list to import.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Import(aList *TPassList) error {
+	if nil == aList {
+		return se.New(errors.New("missing `aList`"), 1)
+	}
+	for user, hash := range aList.usermap {
+		if err := checkIndexed(user, hash); nil != err {
+			return err // already wrapped
+		}
+	}
+
+	il.mtx.Lock()
+	defer il.mtx.Unlock()
+
+	users, err := il.collect()
+	if nil != err {
+		return err // already wrapped
+	}
+	for user, hash := range aList.usermap {
+		users[user] = hash
+	}
+
+	return il.rewrite(users)
+} // Import()
+
+// `IsAuthenticated()` checks `aRequest` for authentication data,
+// returning `nil` for successful authentication, or an `error` otherwise.
+//
+// See [TPassList.IsAuthenticated] for details.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) IsAuthenticated(aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return il.AuthenticateContext(aRequest.Context(), aRequest)
+} // IsAuthenticated()
+
+// `IsProxyAuthenticated()` checks the `Proxy-Authorization` header of
+// `aRequest`, returning `nil` for successful authentication, or an
+// `error` otherwise.
+//
+// See [TPassList.IsProxyAuthenticated] for details.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a proxy.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) IsProxyAuthenticated(aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return il.AuthenticateProxyContext(aRequest.Context(), aRequest)
+} // IsProxyAuthenticated()
+
+// `Len()` returns the number of (active) entries in the user list.
+//
+// Returns:
+//   - `int`: The list's number of entries.
+func (il *TIndexedList) Len() int {
+	il.mtx.RLock()
+	defer il.mtx.RUnlock()
+
+	return il.active
+} // Len()
+
+// `List()` returns a list of all usernames in the list.
+//
+// NOTE: This method reads the whole index file.
+//
+// Returns:
+//   - `[]string`: The users stored in this list.
+func (il *TIndexedList) List() []string {
+	il.mtx.RLock()
+	defer il.mtx.RUnlock()
+
+	users, err := il.collect()
+	if (nil != err) || (0 == len(users)) {
+		return []string{}
+	}
+
+	list := make([]string, 0, len(users))
+	for user := range users {
+		list = append(list, user)
+	}
+	slices.Sort(list) // ascending
+
+	return list
+} // List()
+
+// `Matches()` checks whether `aPassword` of `aUser` matches a stored
+// user/password pair.
+//
+// See [TPassList.Matches] for details.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `bool`: `true` if a match was found, or `false` otherwise.
+func (il *TIndexedList) Matches(aUser, aPassword string) bool {
+	ok, _ := il.MatchesContext(context.Background(), aUser, aPassword)
+
+	return ok
+} // Matches()
+
+// `MatchesContext()` checks whether `aPassword` of `aUser` matches
+// a stored user/password pair.
+//
+// See [TPassList.MatchesContext] for details.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aUser`: The username to lookup.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `bool`: `true` if a match was found, or `false` otherwise.
+//   - `error`: A reason why the verification couldn't be completed.
+func (il *TIndexedList) MatchesContext(aCtx context.Context, aUser, aPassword string) (bool, error) {
+	return il.tVerifier.matches(aCtx, aUser, aPassword, il.Find)
+} // MatchesContext()
+
+// `open()` opens (or creates) the index file and reads its header.
+//
+// NOTE: The caller must hold the write lock (if needed).
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) open() error {
+	file, err := os.OpenFile(il.filename, os.O_RDWR|os.O_CREATE, 0660) // #nosec G302 G304
+	if nil != err {
+		return se.New(err, 1)
+	}
+	il.file = file
+
+	fi, err := file.Stat()
+	if nil != err {
+		_ = il.close()
+		return se.New(err, 1)
+	}
+
+	if 0 == fi.Size() {
+		il.sorted, il.total, il.active = 0, 0, 0
+		if err = il.writeHeader(); nil != err {
+			_ = il.close()
+			return err // already wrapped
+		}
+	} else if err = il.readHeader(fi.Size()); nil != err {
+		_ = il.close()
+		return err // already wrapped
+	}
+
+	if err = il.remap(); nil != err {
+		_ = il.close()
+		return err // already wrapped
+	}
+
+	return nil
+} // open()
+
+// `Put()` stores the password hash `aHashedPW` for `aUser`.
+//
+// An existing user's record is updated in place while new users
+// get appended to the index file. If there are too many unsorted
+// records the file gets compacted.
+//
+// Parameters:
+//   - `aUser` The username to use.
+//   - `aHashedPW` The user's password hash to store.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Put(aUser, aHashedPW string) error {
+	aUser, aHashedPW = strings.TrimSpace(aUser), strings.TrimSpace(aHashedPW)
+	if err := checkIndexed(aUser, aHashedPW); nil != err {
+		return err // already wrapped
+	}
+
+	il.mtx.Lock()
+	defer il.mtx.Unlock()
+
+	idx, err := il.search(aUser)
+	if nil != err {
+		return err // already wrapped
+	}
+
+	if 0 <= idx {
+		rec, err := il.record(idx)
+		if nil != err {
+			return err // already wrapped
+		}
+		revived := (idxActive != rec[0])
+		if err = il.writeRecord(idx, aUser, aHashedPW); nil != err {
+			return err // already wrapped
+		}
+		if !revived {
+			return nil
+		}
+		il.active++
+		if err = il.writeHeader(); nil != err {
+			il.active--
+			return err // already wrapped
+		}
+
+		return nil
+	}
+
+	if err = il.writeRecord(il.total, aUser, aHashedPW); nil != err {
+		return err // already wrapped
+	}
+	il.total++
+	il.active++
+	if err = il.writeHeader(); nil != err {
+		// The appended record is beyond the stored count
+		// and will be overwritten by the next `Put()`.
+		il.total--
+		il.active--
+		return err // already wrapped
+	}
+	// Remap before anything else reads the new record:
+	if err = il.remap(); nil != err {
+		return err // already wrapped
+	}
+
+	if idxMaxExtra < (il.total - il.sorted) {
+		users, err := il.collect()
+		if nil != err {
+			return err // already wrapped
+		}
+		return il.rewrite(users)
+	}
+
+	return nil
+} // Put()
+
+// `readHeader()` reads and checks the index file's header.
+//
+// Parameters:
+//   - `aSize`: The current size of the index file.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) readHeader(aSize int64) error {
+	hdr := make([]byte, idxHdrSize)
+	if _, err := il.file.ReadAt(hdr, 0); nil != err {
+		return se.New(err, 1)
+	}
+	if idxMagic != string(hdr[:len(idxMagic)]) {
+		return se.New(fmt.Errorf("%q: not an indexed password file", il.filename), 1)
+	}
+
+	sorted := binary.LittleEndian.Uint64(hdr[8:16])
+	total := binary.LittleEndian.Uint64(hdr[16:24])
+	active := binary.LittleEndian.Uint64(hdr[24:32])
+	if (sorted > total) || (active > total) ||
+		(uint64(aSize) < idxHdrSize+total*idxRecSize) {
+		return se.New(fmt.Errorf("%q: corrupted index header", il.filename), 1)
+	}
+	il.sorted, il.total, il.active = int(sorted), int(total), int(active)
+
+	return nil
+} // readHeader()
+
+// `record()` returns the record at index `aIdx`.
+//
+// NOTE: The caller must hold (at least) the read lock.
+//
+// Parameters:
+//   - `aIdx`: The index of the record to read.
+//
+// Returns:
+//   - `[]byte`: The requested record.
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) record(aIdx int) ([]byte, error) {
+	offset := idxHdrSize + int64(aIdx)*idxRecSize
+	if int64(len(il.data)) >= offset+idxRecSize {
+		return il.data[offset : offset+idxRecSize], nil
+	}
+	// Either not mapped or a failed `remap()` left a short map.
+
+	rec := make([]byte, idxRecSize)
+	if _, err := il.file.ReadAt(rec, offset); nil != err {
+		return nil, se.New(err, 1)
+	}
+
+	return rec, nil
+} // record()
+
+// `remap()` (re-)creates the memory map of the index file
+// if requested by [OpenIndexed].
+//
+// If the platform doesn't support memory maps the file is read
+// without one.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) remap() error {
+	if !il.mapped {
+		return nil
+	}
+	if nil != il.data {
+		if err := munmapFile(il.data); nil != err {
+			return se.New(err, 1)
+		}
+		il.data = nil
+	}
+
+	data, err := mmapFile(il.file, idxHdrSize+il.total*idxRecSize)
+	if nil != err {
+		if errors.Is(err, errors.ErrUnsupported) {
+			il.mapped = false
+			return nil
+		}
+		return se.New(err, 1)
+	}
+	il.data = data
+
+	return nil
+} // remap()
+
+// `Remove()` deletes `aUser` from the list.
+//
+// The user's record is just marked as removed until the next
+// [TIndexedList.Compact].
+//
+// Parameters:
+//   - `aUser`: The username to remove.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) Remove(aUser string) error {
+	if aUser = strings.TrimSpace(aUser); "" == aUser {
+		return nil
+	}
+
+	il.mtx.Lock()
+	defer il.mtx.Unlock()
+
+	idx, err := il.search(aUser)
+	if (nil != err) || (0 > idx) {
+		return err // `nil` or already wrapped
+	}
+	rec, err := il.record(idx)
+	if nil != err {
+		return err // already wrapped
+	}
+	if idxActive != rec[0] {
+		return nil
+	}
+
+	if _, err = il.file.WriteAt([]byte{idxRemoved}, idxHdrSize+int64(idx)*idxRecSize); nil != err {
+		return se.New(err, 1)
+	}
+	il.active--
+
+	return il.writeHeader()
+} // Remove()
+
+// `rewrite()` replaces the index file by a new one containing all
+// `aUsers` sorted by username.
+//
+// The new file is written under a temporary name first and then
+// renamed to replace the current one.
+//
+// NOTE: The caller must hold the write lock.
+//
+// Parameters:
+//   - `aUsers`: The list of user/hash pairs to store.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) rewrite(aUsers tUserMap) error {
+	names := make([]string, 0, len(aUsers))
+	for user := range aUsers {
+		names = append(names, user)
+	}
+	slices.Sort(names)
+
+	buf := make([]byte, idxHdrSize, idxHdrSize+len(names)*idxRecSize)
+	copy(buf, idxMagic)
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(len(names)))
+	binary.LittleEndian.PutUint64(buf[16:24], uint64(len(names)))
+	binary.LittleEndian.PutUint64(buf[24:32], uint64(len(names)))
+	for _, user := range names {
+		buf = append(buf, newRecord(user, aUsers[user])...)
+	}
+
+	tmpName := il.filename + ".tmp"
+	if err := os.WriteFile(tmpName, buf, 0660); nil != err { // #nosec G306
+		return se.New(err, 1)
+	}
+	if err := il.close(); nil != err {
+		_ = os.Remove(tmpName)
+		return err // already wrapped
+	}
+	if err := os.Rename(tmpName, il.filename); nil != err {
+		_ = os.Remove(tmpName)
+		return se.New(err, 1)
+	}
+
+	return il.open()
+} // rewrite()
+
+// `search()` returns the index of `aUser`'s record, or `-1` if
+// there's no such record.
+//
+// The returned record may be marked as removed.
+//
+// NOTE: The caller must hold (at least) the read lock.
+//
+// Parameters:
+//   - `aUser`: The username to lookup.
+//
+// Returns:
+//   - `int`: The index of the user's record.
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) search(aUser string) (int, error) {
+	lo, hi := 0, il.sorted
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		rec, err := il.record(mid)
+		if nil != err {
+			return -1, err // already wrapped
+		}
+		switch user := recUser(rec); {
+		case user == aUser:
+			return mid, nil
+		case user < aUser:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	for idx := il.sorted; idx < il.total; idx++ {
+		rec, err := il.record(idx)
+		if nil != err {
+			return -1, err // already wrapped
+		}
+		if recUser(rec) == aUser {
+			return idx, nil
+		}
+	}
+
+	return -1, nil
+} // search()
+
+// `SetCache()` sets the cache of verified credentials to use by
+// this list.
+//
+// See [TPassList.SetCache] for details.
+//
+// Parameters:
+//   - `aCache`: The credentials cache to use.
+//
+// Returns:
+//   - `*TIndexedList`: The updated list.
+func (il *TIndexedList) SetCache(aCache *TAuthCache) *TIndexedList {
+	il.cache = aCache
+
+	return il
+} // SetCache()
+
+// `SetLimiter()` sets the limiter of concurrent password verifications
+// to use by this list.
+//
+// See [TPassList.SetLimiter] for details.
+//
+// Parameters:
+//   - `aLimiter`: The verification limiter to use.
+//
+// Returns:
+//   - `*TIndexedList`: The updated list.
+func (il *TIndexedList) SetLimiter(aLimiter *TVerifyLimiter) *TIndexedList {
+	il.limiter = aLimiter
+
+	return il
+} // SetLimiter()
+
+// `writeHeader()` writes the current record counts to the file.
+//
+// NOTE: The caller must hold the write lock.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) writeHeader() error {
+	hdr := make([]byte, idxHdrSize)
+	copy(hdr, idxMagic)
+	binary.LittleEndian.PutUint64(hdr[8:16], uint64(il.sorted))
+	binary.LittleEndian.PutUint64(hdr[16:24], uint64(il.total))
+	binary.LittleEndian.PutUint64(hdr[24:32], uint64(il.active))
+
+	if _, err := il.file.WriteAt(hdr, 0); nil != err {
+		return se.New(err, 1)
+	}
+
+	return nil
+} // writeHeader()
+
+// `writeRecord()` writes an active record for `aUser` at index `aIdx`.
+//
+// NOTE: The caller must hold the write lock.
+//
+// Parameters:
+//   - `aIdx`: The index of the record to write.
+//   - `aUser`: The username to store.
+//   - `aHashedPW`: The user's password hash to store.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (il *TIndexedList) writeRecord(aIdx int, aUser, aHashedPW string) error {
+	offset := idxHdrSize + int64(aIdx)*idxRecSize
+	if _, err := il.file.WriteAt(newRecord(aUser, aHashedPW), offset); nil != err {
+		return se.New(err, 1)
+	}
+
+	return nil
+} // writeRecord()
+
+// --------------------------------------------------------------------------
+// Record helpers:
+
+// `checkIndexed()` checks whether `aUser` and `aHashedPW` fit into
+// an index record.
+//
+// Parameters:
+//   - `aUser`: The username to check.
+//   - `aHashedPW`: The password hash to check.
+//
+// Returns:
+//   - `error`: `nil` if the values can be stored, or an error otherwise.
+func checkIndexed(aUser, aHashedPW string) error {
+	switch {
+	case "" == aUser:
+		return se.New(errors.New("missing/empty username"), 2)
+	case "" == aHashedPW:
+		return se.New(errors.New("missing/empty password hash"), 2)
+	case (idxUserLen < len(aUser)) || strings.ContainsRune(aUser, 0):
+		return se.New(fmt.Errorf("invalid username %q", aUser), 2)
+	case (idxHashLen < len(aHashedPW)) || strings.ContainsRune(aHashedPW, 0):
+		return se.New(errors.New("invalid password hash"), 2)
+	}
+
+	return nil
+} // checkIndexed()
+
+// `newRecord()` returns an active index record for the given values.
+//
+// Parameters:
+//   - `aUser`: The username to store.
+//   - `aHashedPW`: The user's password hash to store.
+//
+// Returns:
+//   - `[]byte`: The new record.
+func newRecord(aUser, aHashedPW string) []byte {
+	rec := make([]byte, idxRecSize)
+	rec[0] = idxActive
+	copy(rec[1:1+idxUserLen], aUser)
+	copy(rec[1+idxUserLen:idxRecSize-1], aHashedPW)
+	rec[idxRecSize-1] = '\n'
+
+	return rec
+} // newRecord()
+
+// `recHash()` returns the password hash stored in `aRecord`.
+//
+// Parameters:
+//   - `aRecord`: The index record to use.
+//
+// Returns:
+//   - `string`: The record's password hash.
+func recHash(aRecord []byte) string {
+	return string(bytes.TrimRight(aRecord[1+idxUserLen:idxRecSize-1], "\x00"))
+} // recHash()
+
+// `recUser()` returns the username stored in `aRecord`.
+//
+// Parameters:
+//   - `aRecord`: The index record to use.
+//
+// Returns:
+//   - `string`: The record's username.
+func recUser(aRecord []byte) string {
+	return string(bytes.TrimRight(aRecord[1:1+idxUserLen], "\x00"))
+} // recUser()
+
+/* _EoF_ */
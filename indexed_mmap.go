@@ -0,0 +1,42 @@
+//go:build unix
+
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+
+package passlist
+
+import (
+	"os"
+	"syscall"
+)
+
+// `mmapFile()` maps the first `aSize` bytes of `aFile` read-only
+// into memory.
+//
+// Parameters:
+//   - `aFile`: The file to map.
+//   - `aSize`: The number of bytes to map.
+//
+// Returns:
+//   - `[]byte`: The mapped file contents.
+//   - `error`: A possible error during processing the request.
+func mmapFile(aFile *os.File, aSize int) ([]byte, error) {
+	return syscall.Mmap(int(aFile.Fd()), 0, aSize, syscall.PROT_READ, syscall.MAP_SHARED)
+} // mmapFile()
+
+// `munmapFile()` releases a memory map created by `mmapFile()`.
+//
+// Parameters:
+//   - `aData`: The mapped file contents.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func munmapFile(aData []byte) error {
+	return syscall.Munmap(aData)
+} // munmapFile()
+
+/* _EoF_ */
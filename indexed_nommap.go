@@ -0,0 +1,41 @@
+//go:build !unix
+
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+
+package passlist
+
+import (
+	"errors"
+	"os"
+)
+
+// `mmapFile()` is a placeholder for platforms without memory maps.
+//
+// Parameters:
+//   - `aFile`: The file to map.
+//   - `aSize`: The number of bytes to map.
+//
+// Returns:
+//   - `[]byte`: `nil`
+//   - `error`: `errors.ErrUnsupported`
+func mmapFile(aFile *os.File, aSize int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+} // mmapFile()
+
+// `munmapFile()` is a placeholder for platforms without memory maps.
+//
+// Parameters:
+//   - `aData`: The mapped file contents.
+//
+// Returns:
+//   - `error`: `nil`
+func munmapFile(aData []byte) error {
+	return nil
+} // munmapFile()
+
+/* _EoF_ */
@@ -0,0 +1,218 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func prepIndexed(t *testing.T, aMapped bool) *TIndexedList {
+	fn := filepath.Join(t.TempDir(), "testlist.idx")
+	il, err := OpenIndexed(fn, aMapped)
+	if nil != err {
+		t.Fatalf("OpenIndexed() error = '%v'", err)
+	}
+	t.Cleanup(func() {
+		_ = il.Close()
+	})
+
+	return il
+} // prepIndexed()
+
+func Test_OpenIndexed(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.idx")
+	_ = os.WriteFile(bad, []byte("username1:password1\n"), 0600)
+
+	tests := []struct {
+		name     string
+		filename string
+		wantErr  bool
+	}{
+		{" 1", filepath.Join(dir, "new.idx"), false},
+		{" 2", "   ", true},
+		{" 3", bad, true},
+		{" 4", filepath.Join(dir, "missing", "new.idx"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenIndexed(tt.filename, false)
+			if (nil != err) != tt.wantErr {
+				t.Errorf("OpenIndexed() error = '%v', wantErr '%v'",
+					err, tt.wantErr)
+			}
+			if nil != got {
+				_ = got.Close()
+			}
+		})
+	}
+} // Test_OpenIndexed()
+
+func Test_TIndexedList_Put(t *testing.T) {
+	for _, mapped := range []bool{false, true} {
+		il := prepIndexed(t, mapped)
+		u1, p1 := "username1", "password1"
+		u2, p2 := "username2", "password2"
+		u3, p3 := "username3", "password3"
+
+		for _, user := range []string{u2, u1} {
+			if err := il.Put(user, "dummy"); nil != err {
+				t.Fatalf("TIndexedList.Put() error = '%v'", err)
+			}
+		}
+		if err := il.Compact(); nil != err {
+			t.Fatalf("TIndexedList.Compact() error = '%v'", err)
+		}
+		_ = il.Put(u3, p3) // unsorted record
+		_ = il.Put(u1, p1) // in-place update
+		_ = il.Put(u2, p2)
+
+		tests := []struct {
+			name    string
+			user    string
+			want    string
+			wantErr bool
+		}{
+			{" 1", u1, p1, false},
+			{" 2", u2, p2, false},
+			{" 3", u3, p3, false},
+			{" 4", "nobody", "", true},
+			{" 5", "", "", true},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s mapped=%v", tt.name, mapped), func(t *testing.T) {
+				got, err := il.Find(tt.user)
+				if (nil != err) != tt.wantErr {
+					t.Errorf("TIndexedList.Find() error = '%v', wantErr '%v'",
+						err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("TIndexedList.Find() = %q, want %q",
+						got, tt.want)
+				}
+			})
+		}
+
+		if err := il.Put("", p1); nil == err {
+			t.Errorf("TIndexedList.Put() accepted empty username")
+		}
+		if err := il.Put(string(make([]byte, idxUserLen+1)), p1); nil == err {
+			t.Errorf("TIndexedList.Put() accepted too long username")
+		}
+	}
+} // Test_TIndexedList_Put()
+
+func Test_TIndexedList_Remove(t *testing.T) {
+	il := prepIndexed(t, false)
+	u1, u2, u3 := "username1", "username2", "username3"
+	_ = il.Put(u1, "hash1")
+	_ = il.Put(u2, "hash2")
+	_ = il.Compact()
+	_ = il.Put(u3, "hash3")
+
+	_ = il.Remove(u1) // sorted record
+	_ = il.Remove(u3) // unsorted record
+	_ = il.Remove("nobody")
+
+	if got := il.Len(); 1 != got {
+		t.Errorf("TIndexedList.Len() = %d, want %d", got, 1)
+	}
+	if got, want := il.List(), []string{u2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TIndexedList.List() = %v, want %v", got, want)
+	}
+	if il.Exists(u1) || il.Exists(u3) {
+		t.Errorf("TIndexedList.Exists() found removed user")
+	}
+
+	// re-adding a removed user reuses its record
+	_ = il.Put(u1, "hash4")
+	if got, _ := il.Find(u1); "hash4" != got {
+		t.Errorf("TIndexedList.Find() = %q, want %q", got, "hash4")
+	}
+	if err := il.Compact(); nil != err {
+		t.Fatalf("TIndexedList.Compact() error = '%v'", err)
+	}
+	if 2 != il.total || 2 != il.sorted {
+		t.Errorf("TIndexedList.Compact() total = %d, sorted = %d, want 2",
+			il.total, il.sorted)
+	}
+} // Test_TIndexedList_Remove()
+
+func Test_TIndexedList_Import(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	u2, p2 := "username2", "password2"
+	ul := prepDB().add0(u1, xxHash(p1)).add0(u2, xxHash(p2))
+
+	il := prepIndexed(t, true)
+	if err := il.Import(ul); nil != err {
+		t.Fatalf("TIndexedList.Import() error = '%v'", err)
+	}
+	fn := il.filename
+	_ = il.Close()
+
+	// the data must survive re-opening the file
+	il, err := OpenIndexed(fn, true)
+	if nil != err {
+		t.Fatalf("OpenIndexed() error = '%v'", err)
+	}
+	defer il.Close()
+
+	if got := il.Len(); 2 != got {
+		t.Errorf("TIndexedList.Len() = %d, want %d", got, 2)
+	}
+	if !il.Matches(u1, p1) || !il.Matches(u2, p2) {
+		t.Errorf("TIndexedList.Matches() = false, want true")
+	}
+	if il.Matches(u1, p2) {
+		t.Errorf("TIndexedList.Matches() = true, want false")
+	}
+} // Test_TIndexedList_Import()
+
+func Test_TIndexedList_autoCompact(t *testing.T) {
+	for _, mapped := range []bool{false, true} {
+		il := prepIndexed(t, mapped)
+		for i := 0; i <= idxMaxExtra; i++ {
+			if err := il.Put(fmt.Sprintf("user%05d", i), "hash"); nil != err {
+				t.Fatalf("TIndexedList.Put() error = '%v'", err)
+			}
+		}
+
+		if il.sorted != il.total {
+			t.Errorf("TIndexedList.Put() sorted = %d, want %d (mapped=%v)",
+				il.sorted, il.total, mapped)
+		}
+		if _, err := il.Find("user00512"); nil != err {
+			t.Errorf("TIndexedList.Find() error = '%v' (mapped=%v)",
+				err, mapped)
+		}
+	}
+} // Test_TIndexedList_autoCompact()
+
+func Test_TIndexedList_shortMap(t *testing.T) {
+	il := prepIndexed(t, true)
+	for _, user := range []string{"username1", "username2"} {
+		if err := il.Put(user, "hash"); nil != err {
+			t.Fatalf("TIndexedList.Put() error = '%v'", err)
+		}
+	}
+	if nil == il.data {
+		t.Skip("memory maps not supported")
+	}
+
+	// Simulate a failed `remap()` leaving the old, shorter map:
+	il.data = il.data[:idxHdrSize+idxRecSize]
+	if got, err := il.Find("username2"); (nil != err) || ("hash" != got) {
+		t.Errorf("TIndexedList.Find() = %q, '%v', want %q, nil",
+			got, err, "hash")
+	}
+} // Test_TIndexedList_shortMap()
+
+/* _EoF_ */
@@ -0,0 +1,571 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the issuance and validation of signed JSON Web
+ * Tokens (RFC 7519) for clients like single page applications.
+ */
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `JWTEdDSA` is the name of the Ed25519 signature algorithm.
+	JWTEdDSA = "EdDSA"
+
+	// `JWTHS256` is the name of the HMAC SHA-256 signature algorithm.
+	JWTHS256 = "HS256"
+
+	// Min. length (in bytes) of an HS256 secret.
+	jwtMinSecret = 32
+)
+
+var (
+	// `ErrTokenExpired` is returned (and passed to [THooks.OnFailure])
+	// if a token's expiry time has passed.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+type (
+	// `TAudience` is the list of a token's audiences; it's encoded as
+	// a single string if there's just one audience.
+	TAudience []string
+
+	// `TJWTClaims` holds the claims of the tokens issued by [TJWT].
+	TJWTClaims struct {
+		Issuer    string    `json:"iss,omitempty"`   // the token's issuer
+		Subject   string    `json:"sub"`             // the user's name
+		Audience  TAudience `json:"aud,omitempty"`   // the token's recipients
+		Expires   int64     `json:"exp"`             // expiry time (Unix seconds)
+		NotBefore int64     `json:"nbf,omitempty"`   // start of validity (Unix seconds)
+		IssuedAt  int64     `json:"iat,omitempty"`   // issue time (Unix seconds)
+		ID        string    `json:"jti,omitempty"`   // the token's unique ID
+		Roles     []string  `json:"roles,omitempty"` // the user's roles
+		Scope     string    `json:"scope,omitempty"` // space separated scopes
+	}
+
+	// `tJWTHeader` is the JOSE header of a token.
+	tJWTHeader struct {
+		Alg string `json:"alg"`
+		Typ string `json:"typ,omitempty"`
+		Kid string `json:"kid,omitempty"`
+	}
+
+	// `tJWTKey` is a single signing/verification key.
+	tJWTKey struct {
+		alg     string             // [JWTHS256] or [JWTEdDSA]
+		secret  []byte             // HS256 shared secret
+		private ed25519.PrivateKey // EdDSA signing key (optional)
+		public  ed25519.PublicKey  // EdDSA verification key
+	}
+
+	// `TJWT` issues and validates signed JSON Web Tokens.
+	//
+	// The tokens are signed either by HMAC SHA-256 with a shared
+	// secret ([JWTHS256]) or by Ed25519 ([JWTEdDSA]). Each key is
+	// identified by an ID sent in the token's `kid` header; to rotate
+	// the keys add a new one (which is used for signing from then on)
+	// and remove the old one once all tokens signed by it expired.
+	TJWT struct {
+		mtx      sync.RWMutex       // protect concurrent access
+		keys     map[string]tJWTKey // keys by ID
+		current  string             // ID of the signing key
+		issuer   string             // the tokens' issuer
+		audience string             // the tokens' audience
+		ttl      time.Duration      // lifetime of issued tokens
+		leeway   time.Duration      // tolerated clock skew
+	}
+)
+
+// `NewJWT()` returns a new token issuer/validator.
+//
+// Validated tokens must name `aIssuer` as their issuer and `aAudience`
+// as (one of) their audience(s) unless the respective value is empty.
+// A key has to be added before tokens can be issued or validated.
+//
+// Parameters:
+//   - `aIssuer`: The tokens' issuer (e.g. the site's URL).
+//   - `aAudience`: The tokens' audience (e.g. the API's URL).
+//   - `aTTL`: The lifetime of issued tokens (default: 15 minutes).
+//
+// Returns:
+//   - `*TJWT`: The new token issuer/validator.
+func NewJWT(aIssuer, aAudience string, aTTL time.Duration) *TJWT {
+	if 0 >= aTTL {
+		aTTL = 15 * time.Minute
+	}
+
+	return &TJWT{
+		keys:     make(map[string]tJWTKey, 4),
+		issuer:   strings.TrimSpace(aIssuer),
+		audience: strings.TrimSpace(aAudience),
+		ttl:      aTTL,
+		leeway:   30 * time.Second,
+	}
+} // NewJWT()
+
+// --------------------------------------------------------------------------
+// `TAudience` methods:
+
+// `MarshalJSON()` encodes a single audience as a plain string.
+//
+// Returns:
+//   - `[]byte`: The JSON encoded audience(s).
+//   - `error`: A possible error during processing the request.
+func (a TAudience) MarshalJSON() ([]byte, error) {
+	if 1 == len(a) {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+} // MarshalJSON()
+
+// `UnmarshalJSON()` decodes either a single string or a list of them.
+//
+// Parameters:
+//   - `aData`: The JSON encoded audience(s).
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (a *TAudience) UnmarshalJSON(aData []byte) error {
+	var single string
+	if err := json.Unmarshal(aData, &single); nil == err {
+		*a = TAudience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(aData, &list); nil != err {
+		return err
+	}
+	*a = list
+
+	return nil
+} // UnmarshalJSON()
+
+// --------------------------------------------------------------------------
+// `TJWT` methods:
+
+// `AddEdDSAKey()` adds an Ed25519 key and uses it for signing
+// from now on.
+//
+// Parameters:
+//   - `aID`: The key's ID.
+//   - `aKey`: The private key.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (j *TJWT) AddEdDSAKey(aID string, aKey ed25519.PrivateKey) error {
+	if ed25519.PrivateKeySize != len(aKey) {
+		return se.New(errors.New("invalid Ed25519 private key"), 1)
+	}
+	public, _ := aKey.Public().(ed25519.PublicKey)
+
+	return j.addKey(aID, tJWTKey{alg: JWTEdDSA, private: aKey, public: public}, true)
+} // AddEdDSAKey()
+
+// `AddHS256Key()` adds an HMAC SHA-256 secret and uses it for
+// signing from now on.
+//
+// Parameters:
+//   - `aID`: The key's ID.
+//   - `aSecret`: The shared secret (at least 32 bytes).
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (j *TJWT) AddHS256Key(aID string, aSecret []byte) error {
+	if jwtMinSecret > len(aSecret) {
+		return se.New(fmt.Errorf("HS256 secret shorter than %d bytes", jwtMinSecret), 1)
+	}
+
+	return j.addKey(aID, tJWTKey{alg: JWTHS256, secret: slices.Clone(aSecret)}, true)
+} // AddHS256Key()
+
+// `addKey()` stores `aKey` with `aID`.
+//
+// Parameters:
+//   - `aID`: The key's ID.
+//   - `aKey`: The key to store.
+//   - `aSign`: Whether to use the key for signing from now on.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (j *TJWT) addKey(aID string, aKey tJWTKey, aSign bool) error {
+	if aID = strings.TrimSpace(aID); "" == aID {
+		return se.New(errors.New("missing/empty key ID"), 2)
+	}
+
+	j.mtx.Lock()
+	j.keys[aID] = aKey
+	if aSign {
+		j.current = aID
+	}
+	j.mtx.Unlock()
+
+	return nil
+} // addKey()
+
+// `AddPublicKey()` adds an Ed25519 public key used for validating
+// tokens issued elsewhere.
+//
+// Parameters:
+//   - `aID`: The key's ID.
+//   - `aKey`: The public key.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (j *TJWT) AddPublicKey(aID string, aKey ed25519.PublicKey) error {
+	if ed25519.PublicKeySize != len(aKey) {
+		return se.New(errors.New("invalid Ed25519 public key"), 1)
+	}
+
+	return j.addKey(aID, tJWTKey{alg: JWTEdDSA, public: aKey}, false)
+} // AddPublicKey()
+
+// `Issue()` returns a new signed token for `aUser`.
+//
+// Parameters:
+//   - `aUser`: The user's name.
+//   - `aRoles`: The user's roles (may be empty).
+//   - `aScopes`: The scopes to grant (may be empty).
+//
+// Returns:
+//   - `string`: The signed token.
+//   - `TJWTClaims`: The token's claims.
+//   - `error`: A possible error during processing the request.
+func (j *TJWT) Issue(aUser string, aRoles, aScopes []string) (string, TJWTClaims, error) {
+	j.mtx.RLock()
+	kid := j.current
+	key, ok := j.keys[kid]
+	j.mtx.RUnlock()
+	if !ok || ((JWTEdDSA == key.alg) && (nil == key.private)) {
+		return "", TJWTClaims{}, se.New(errors.New("missing signing key"), 1)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); nil != err {
+		return "", TJWTClaims{}, se.New(err, 1)
+	}
+	now := time.Now()
+	claims := TJWTClaims{
+		Issuer:   j.issuer,
+		Subject:  aUser,
+		Expires:  now.Add(j.ttl).Unix(),
+		IssuedAt: now.Unix(),
+		ID:       hex.EncodeToString(id),
+		Roles:    aRoles,
+		Scope:    strings.Join(aScopes, " "),
+	}
+	if "" != j.audience {
+		claims.Audience = TAudience{j.audience}
+	}
+
+	header, _ := json.Marshal(tJWTHeader{Alg: key.alg, Typ: "JWT", Kid: kid})
+	payload, err := json.Marshal(claims)
+	if nil != err {
+		return "", TJWTClaims{}, se.New(err, 1)
+	}
+	input := jwtEncode(header) + "." + jwtEncode(payload)
+
+	return input + "." + jwtEncode(key.sign(input)), claims, nil
+} // Issue()
+
+// `RemoveKey()` removes the key `aID` so tokens signed by it are
+// refused from now on.
+//
+// Parameters:
+//   - `aID`: The key's ID.
+//
+// Returns:
+//   - `*TJWT`: The updated issuer/validator.
+func (j *TJWT) RemoveKey(aID string) *TJWT {
+	j.mtx.Lock()
+	delete(j.keys, aID)
+	if j.current == aID {
+		j.current = ""
+	}
+	j.mtx.Unlock()
+
+	return j
+} // RemoveKey()
+
+// `SetLeeway()` sets the tolerated clock skew when checking the
+// tokens' times (default: 30 seconds).
+//
+// Parameters:
+//   - `aLeeway`: The tolerated clock skew.
+//
+// Returns:
+//   - `*TJWT`: The updated issuer/validator.
+func (j *TJWT) SetLeeway(aLeeway time.Duration) *TJWT {
+	if 0 > aLeeway {
+		aLeeway = 0
+	}
+	j.mtx.Lock()
+	j.leeway = aLeeway
+	j.mtx.Unlock()
+
+	return j
+} // SetLeeway()
+
+// `Validate()` checks `aToken` and returns its claims.
+//
+// The token's signature has to be made by a known key using the
+// key's algorithm; it must not be expired (or not yet valid), and
+// its issuer and audience must match the configured ones.
+//
+// Parameters:
+//   - `aToken`: The token sent by a client.
+//
+// Returns:
+//   - `*TJWTClaims`: The token's claims.
+//   - `error`: [ErrInvalidCredentials], [ErrTokenExpired], or `nil`.
+func (j *TJWT) Validate(aToken string) (*TJWTClaims, error) {
+	parts := strings.Split(aToken, ".")
+	if 3 != len(parts) {
+		return nil, ErrInvalidCredentials
+	}
+	var header tJWTHeader
+	if !jwtDecode(parts[0], &header) {
+		return nil, ErrInvalidCredentials
+	}
+
+	j.mtx.RLock()
+	key, ok := j.keys[header.Kid]
+	leeway := j.leeway
+	j.mtx.RUnlock()
+	if !ok || (header.Alg != key.alg) {
+		// unknown key or algorithm confusion (incl. "none")
+		return nil, ErrInvalidCredentials
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if (nil != err) || !key.verify(parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidCredentials
+	}
+
+	claims := new(TJWTClaims)
+	if !jwtDecode(parts[1], claims) || ("" == claims.Subject) || (0 == claims.Expires) {
+		return nil, ErrInvalidCredentials
+	}
+	if ("" != j.issuer) && (claims.Issuer != j.issuer) {
+		return nil, ErrInvalidCredentials
+	}
+	if ("" != j.audience) && !slices.Contains(claims.Audience, j.audience) {
+		return nil, ErrInvalidCredentials
+	}
+	now := time.Now()
+	if (0 != claims.NotBefore) && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, ErrInvalidCredentials
+	}
+	if !now.Add(-leeway).Before(time.Unix(claims.Expires, 0)) {
+		return claims, ErrTokenExpired
+	}
+
+	return claims, nil
+} // Validate()
+
+// --------------------------------------------------------------------------
+// `tJWTKey` methods:
+
+// `sign()` returns the signature of `aInput`.
+//
+// Parameters:
+//   - `aInput`: The JWS signing input ("header.payload").
+//
+// Returns:
+//   - `[]byte`: The signature.
+func (k tJWTKey) sign(aInput string) []byte {
+	if JWTEdDSA == k.alg {
+		return ed25519.Sign(k.private, []byte(aInput))
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(aInput))
+
+	return mac.Sum(nil)
+} // sign()
+
+// `verify()` checks `aSignature` of `aInput`.
+//
+// Parameters:
+//   - `aInput`: The JWS signing input ("header.payload").
+//   - `aSignature`: The signature to check.
+//
+// Returns:
+//   - `bool`: `true` if the signature is valid, or `false` otherwise.
+func (k tJWTKey) verify(aInput string, aSignature []byte) bool {
+	if JWTEdDSA == k.alg {
+		return ed25519.Verify(k.public, []byte(aInput), aSignature)
+	}
+
+	return hmac.Equal(k.sign(aInput), aSignature)
+} // verify()
+
+// --------------------------------------------------------------------------
+// `TMiddleware` methods:
+
+// `authenticateToken()` checks the token sent with `aRequest`.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aToken`: The token sent by the remote host.
+//
+// Returns:
+//   - `*http.Request`: The authenticated request, or `nil` if it was denied.
+func (mw *TMiddleware) authenticateToken(aWriter http.ResponseWriter, aRequest *http.Request, aToken string) *http.Request {
+	claims, err := mw.jwt.Validate(aToken)
+	if nil != err {
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, err)
+		}
+		mw.denyRequest(aWriter, aRequest, err)
+		return nil
+	}
+
+	return mw.accept(aRequest, tokenPrincipal(claims))
+} // authenticateToken()
+
+// `TokenHandler()` returns a handler issuing tokens (see [WithJWT]).
+//
+// The handler accepts `POST` requests carrying the user's Basic (or
+// Digest) credentials, which are verified against the user list the
+// same way as by [TMiddleware.Wrap] (including a configured lockout);
+// neither sessions nor tokens nor API keys are accepted. It answers
+// with a JSON object like
+//
+//	{"access_token":"…","token_type":"Bearer","expires_in":900}
+//
+// The token carries the user's roles (see [WithRoles]). Clients send
+// it as `Authorization: Bearer <token>` with their further requests.
+//
+// Returns:
+//   - `http.Handler`: The token endpoint.
+func (mw *TMiddleware) TokenHandler() http.Handler {
+	if nil == mw.jwt {
+		mw.logger.Printf("passlist.TokenHandler(): missing JWT configuration\nDENYING ALL REQUESTS!\n")
+	}
+
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		if http.MethodPost != aRequest.Method {
+			aWriter.Header().Set("Allow", http.MethodPost)
+			mw.deny(aWriter, aRequest, http.StatusMethodNotAllowed)
+			return
+		}
+		if nil == mw.jwt {
+			mw.deny(aWriter, aRequest, http.StatusServiceUnavailable)
+			return
+		}
+		if aRequest = mw.authenticate(aWriter, aRequest, true); nil == aRequest {
+			return // response already sent
+		}
+
+		p := PrincipalFromContext(aRequest.Context())
+		token, claims, err := mw.jwt.Issue(p.Name, p.Roles, nil)
+		if nil != err {
+			mw.logger.Printf("passlist.TokenHandler(): %v\n", err)
+			mw.deny(aWriter, aRequest, http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := json.Marshal(struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+			ExpiresIn   int64  `json:"expires_in"`
+		}{token, "Bearer", claims.Expires - claims.IssuedAt})
+
+		aWriter.Header().Set("Content-Type", "application/json")
+		aWriter.Header().Set("Cache-Control", "no-store")
+		aWriter.WriteHeader(http.StatusOK)
+		_, _ = aWriter.Write(append(body, '\n'))
+	})
+} // TokenHandler()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `isJWT()` returns whether `aToken` looks like a JSON Web Token.
+//
+// Parameters:
+//   - `aToken`: The token to check.
+//
+// Returns:
+//   - `bool`: `true` if the token has three dot separated parts.
+func isJWT(aToken string) bool {
+	return 2 == strings.Count(aToken, ".")
+} // isJWT()
+
+// `jwtDecode()` decodes the base64url encoded JSON `aPart` into `aValue`.
+//
+// Parameters:
+//   - `aPart`: The encoded token part.
+//   - `aValue`: Pointer to the value to fill.
+//
+// Returns:
+//   - `bool`: `true` if decoding was successful.
+func jwtDecode(aPart string, aValue any) bool {
+	data, err := base64.RawURLEncoding.DecodeString(aPart)
+	if nil != err {
+		return false
+	}
+
+	return nil == json.Unmarshal(data, aValue)
+} // jwtDecode()
+
+// `jwtEncode()` returns the base64url encoding of `aData`.
+//
+// Parameters:
+//   - `aData`: The data to encode.
+//
+// Returns:
+//   - `string`: The encoded data (without padding).
+func jwtEncode(aData []byte) string {
+	return base64.RawURLEncoding.EncodeToString(aData)
+} // jwtEncode()
+
+// `tokenPrincipal()` returns the principal described by `aClaims`.
+//
+// Parameters:
+//   - `aClaims`: The claims of a validated token.
+//
+// Returns:
+//   - `*TPrincipal`: The token's principal.
+func tokenPrincipal(aClaims *TJWTClaims) *TPrincipal {
+	p := &TPrincipal{
+		Name:       aClaims.Subject,
+		Roles:      aClaims.Roles,
+		Scopes:     strings.Fields(aClaims.Scope),
+		Attributes: map[string]string{"jti": aClaims.ID},
+		AuthMethod: AuthJWT,
+		AuthTime:   time.Now(),
+	}
+	if 0 != aClaims.IssuedAt {
+		p.AuthTime = time.Unix(aClaims.IssuedAt, 0)
+	}
+
+	return p
+} // tokenPrincipal()
+
+/* _EoF_ */
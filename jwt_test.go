@@ -0,0 +1,214 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jwtSecret is an internal test helper providing an HS256 secret.
+var jwtSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func Test_TJWT_Validate(t *testing.T) {
+	hs := NewJWT("https://example.com", "api", time.Minute)
+	if err := hs.AddHS256Key("k1", []byte("too short")); nil == err {
+		t.Error("TJWT.AddHS256Key() expected error for short secret")
+	}
+	_ = hs.AddHS256Key("k1", jwtSecret)
+	t1, claims, err := hs.Issue("username1", []string{"admin"}, []string{"read", "write"})
+	if nil != err {
+		t.Fatalf("TJWT.Issue() error = %v", err)
+	}
+	if "username1" != claims.Subject || "" == claims.ID {
+		t.Errorf("TJWT.Issue() claims = %+v", claims)
+	}
+
+	_, private, _ := ed25519.GenerateKey(nil)
+	ed := NewJWT("https://example.com", "api", time.Minute)
+	_ = ed.AddEdDSAKey("e1", private)
+	t2, _, _ := ed.Issue("username2", nil, nil)
+
+	// validator knowing only the public key:
+	pub := NewJWT("https://example.com", "api", time.Minute)
+	_ = pub.AddPublicKey("e1", private.Public().(ed25519.PublicKey))
+
+	other := NewJWT("https://example.com", "other", time.Minute)
+	_ = other.AddHS256Key("k1", jwtSecret)
+	t3, _, _ := other.Issue("username1", nil, nil)
+
+	// token expired a minute ago:
+	payload, _ := json.Marshal(TJWTClaims{Issuer: "https://example.com",
+		Subject: "username1", Audience: TAudience{"api"},
+		Expires: time.Now().Add(-time.Minute).Unix()})
+	input := jwtEncode([]byte(`{"alg":"HS256","kid":"k1"}`)) + "." + jwtEncode(payload)
+	t4 := input + "." + jwtEncode(hs.keys["k1"].sign(input))
+
+	// alg "none" using the HS256 key's ID:
+	parts := strings.Split(t1, ".")
+	t5 := jwtEncode([]byte(`{"alg":"none","kid":"k1"}`)) + "." + parts[1] + "."
+
+	// EdDSA signed token claiming to be HS256:
+	eparts := strings.Split(t2, ".")
+	t6 := jwtEncode([]byte(`{"alg":"HS256","kid":"e1"}`)) + "." + eparts[1] + "." + eparts[2]
+
+	tests := []struct {
+		name      string
+		validator *TJWT
+		token     string
+		wantSub   string
+		wantErr   error
+	}{
+		{" 1", hs, t1, "username1", nil},
+		{" 2", ed, t2, "username2", nil},
+		{" 3", pub, t2, "username2", nil},
+		{" 4", hs, t2, "", ErrInvalidCredentials},
+		{" 5", hs, t3, "", ErrInvalidCredentials},
+		{" 6", hs, t4, "username1", ErrTokenExpired},
+		{" 7", hs, t5, "", ErrInvalidCredentials},
+		{" 8", pub, t6, "", ErrInvalidCredentials},
+		{" 9", hs, t1[:len(t1)-2] + "AA", "", ErrInvalidCredentials},
+		{"10", hs, "not.a.token", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.validator.Validate(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TJWT.Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if "" != tt.wantSub && ((nil == got) || (got.Subject != tt.wantSub)) {
+				t.Errorf("TJWT.Validate() = %+v, want subject %q", got, tt.wantSub)
+			}
+		})
+	}
+} // Test_TJWT_Validate()
+
+func Test_TJWT_rotation(t *testing.T) {
+	j := NewJWT("", "", time.Minute)
+	_ = j.AddHS256Key("old", jwtSecret)
+	t1, _, _ := j.Issue("username1", nil, nil)
+	_ = j.AddHS256Key("new", []byte(strings.Repeat("x", 32)))
+	t2, _, _ := j.Issue("username1", nil, nil)
+
+	if _, err := j.Validate(t1); nil != err {
+		t.Errorf("TJWT.Validate(old) error = %v", err)
+	}
+	if _, err := j.Validate(t2); nil != err {
+		t.Errorf("TJWT.Validate(new) error = %v", err)
+	}
+	j.RemoveKey("old")
+	if _, err := j.Validate(t1); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("TJWT.Validate(removed) error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, _, err := j.RemoveKey("new").Issue("username1", nil, nil); nil == err {
+		t.Error("TJWT.Issue() expected error without signing key")
+	}
+} // Test_TJWT_rotation()
+
+func Test_TJWT_SetLeeway(t *testing.T) {
+	j := NewJWT("", "", time.Minute)
+	_ = j.AddHS256Key("k1", jwtSecret)
+	token, _, _ := j.Issue("username1", nil, nil)
+
+	// run with `-race` to detect unsynchronised access:
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			j.SetLeeway(time.Duration(i) * time.Second)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := j.Validate(token); nil != err {
+			t.Fatalf("TJWT.Validate() error = %v", err)
+		}
+	}
+	<-done
+} // Test_TJWT_SetLeeway()
+
+func Test_TMiddleware_TokenHandler(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	j := NewJWT("https://example.com", "api", time.Minute)
+	_ = j.AddHS256Key("k1", jwtSecret)
+
+	var principal *TPrincipal
+	mw := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithJWT(j),
+		WithRoles(func(aUser string) []string { return []string{"staff"} }),
+		WithLogger(quietLogger),
+	)
+	handler := mw.Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		principal = PrincipalFromContext(aRequest.Context())
+		aWriter.WriteHeader(http.StatusOK)
+	}))
+	endpoint := mw.TokenHandler()
+
+	// request a token:
+	req := httptest.NewRequest("POST", "http://example.com/token", nil)
+	req.SetBasicAuth(u1, p1)
+	rec := httptest.NewRecorder()
+	endpoint.ServeHTTP(rec, req)
+	if http.StatusOK != rec.Code {
+		t.Fatalf("TMiddleware.TokenHandler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); (nil != err) ||
+		("Bearer" != resp.TokenType) || (60 != resp.ExpiresIn) {
+		t.Fatalf("TMiddleware.TokenHandler() body = %q (%v)", rec.Body.String(), err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		auth   string
+		target http.Handler
+		want   int
+	}{
+		{" 1", "GET", "Bearer " + resp.AccessToken, handler, http.StatusOK},
+		{" 2", "GET", "Bearer " + resp.AccessToken + "x", handler, http.StatusUnauthorized},
+		{" 3", "POST", "Bearer " + resp.AccessToken, endpoint, http.StatusUnauthorized},
+		{" 4", "GET", "", endpoint, http.StatusMethodNotAllowed},
+		{" 5", "POST", "", endpoint, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(tt.method, "http://example.com/", nil)
+			if "" != tt.auth {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			tt.target.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if http.StatusOK != tt.want {
+				return
+			}
+			if (nil == principal) || (u1 != principal.Name) ||
+				(AuthJWT != principal.AuthMethod) || !principal.HasRole("staff") {
+				t.Errorf("principal = %+v, want %q", principal, u1)
+			}
+		})
+	}
+} // Test_TMiddleware_TokenHandler()
+
+/* _EoF_ */
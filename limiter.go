@@ -0,0 +1,226 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides a limiter for the number of password
+ * verifications running concurrently as well as the errors
+ * signalling an aborted verification.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+var (
+	// `ErrBusy` is returned if a password verification couldn't get
+	// a free slot within the limiter's waiting time.
+	ErrBusy = errors.New("too many concurrent password verifications")
+
+	// `ErrCanceled` is returned if a password verification was
+	// abandoned because its context was cancelled.
+	ErrCanceled = errors.New("password verification cancelled")
+
+	// `ErrTimeout` is returned if a password verification was
+	// abandoned because its context's deadline expired.
+	ErrTimeout = errors.New("password verification timed out")
+
+	// Package-wide limiter used by all lists without their own one.
+	pwLimiter atomic.Pointer[TVerifyLimiter]
+)
+
+type (
+	// `TVerifyLimiter` bounds the number of password hash
+	// verifications running at the same time.
+	//
+	// Each verification (i.e. a `bcrypt` compare) keeps a CPU core
+	// busy for a noticeable amount of time. Without a limit a remote
+	// client sending random credentials could starve all the other
+	// requests handled by the server.
+	TVerifyLimiter struct {
+		slots chan struct{} // semaphore of available slots
+		wait  time.Duration // max. time to wait for a free slot
+	}
+)
+
+// `NewVerifyLimiter()` returns a new limiter allowing `aMax`
+// concurrent verifications.
+//
+// If `aMax` is zero or negative the function returns `nil`
+// (i.e. no limit at all).
+//
+// Parameters:
+//   - `aMax`: The max. number of concurrent verifications.
+//   - `aWait`: The max. time to wait for a free verification slot.
+//
+// Returns:
+//   - `*TVerifyLimiter`: The new limiter.
+func NewVerifyLimiter(aMax int, aWait time.Duration) *TVerifyLimiter {
+	if 0 >= aMax {
+		return nil
+	}
+	if 0 > aWait {
+		aWait = 0
+	}
+
+	return &TVerifyLimiter{
+		slots: make(chan struct{}, aMax),
+		wait:  aWait,
+	}
+} // NewVerifyLimiter()
+
+// `SetVerifyLimit()` sets the package-wide limit of concurrent
+// password verifications used by all lists that don't have their
+// own limiter (see [TPassList.SetLimiter]).
+//
+// If `aMax` is zero or negative the package-wide limit is removed.
+//
+// Parameters:
+//   - `aMax`: The max. number of concurrent verifications.
+//   - `aWait`: The max. time to wait for a free verification slot.
+func SetVerifyLimit(aMax int, aWait time.Duration) {
+	pwLimiter.Store(NewVerifyLimiter(aMax, aWait))
+} // SetVerifyLimit()
+
+// --------------------------------------------------------------------------
+// `TVerifyLimiter` methods:
+
+// `acquire()` waits for a free verification slot.
+//
+// A `nil` limiter always succeeds unless `aCtx` is already done.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the waiting time.
+//
+// Returns:
+//   - `error`: `ErrBusy` if no slot became available in time, or an
+//     error matching `ErrCanceled` or `ErrTimeout` if `aCtx` is done.
+func (vl *TVerifyLimiter) acquire(aCtx context.Context) error {
+	if nil != aCtx.Err() {
+		return contextError(aCtx)
+	}
+	if nil == vl {
+		return nil
+	}
+
+	select {
+	case vl.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if 0 == vl.wait {
+		return ErrBusy
+	}
+
+	timer := time.NewTimer(vl.wait)
+	defer timer.Stop()
+
+	select {
+	case vl.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBusy
+	case <-aCtx.Done():
+		return contextError(aCtx)
+	}
+} // acquire()
+
+// `release()` frees a verification slot taken by `acquire()`.
+func (vl *TVerifyLimiter) release() {
+	if nil != vl {
+		<-vl.slots
+	}
+} // release()
+
+// `RetryAfter()` returns the time a client should wait before
+// retrying a request rejected with `ErrBusy`.
+//
+// Returns:
+//   - `time.Duration`: The suggested delay (at least one second).
+func (vl *TVerifyLimiter) RetryAfter() time.Duration {
+	if (nil == vl) || (time.Second > vl.wait) {
+		return time.Second
+	}
+
+	return vl.wait
+} // RetryAfter()
+
+// --------------------------------------------------------------------------
+
+// `Busy()` sends a "Service Unavailable" notice to the remote host
+// telling it to retry after `aRetryAfter`.
+//
+// Parameters:
+//   - `aRetryAfter`: The time the remote host should wait.
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+func Busy(aRetryAfter time.Duration, aWriter http.ResponseWriter) {
+	setRetryAfter(aWriter, aRetryAfter)
+	http.Error(aWriter, "503 Service Unavailable", http.StatusServiceUnavailable)
+} // Busy()
+
+// `setRetryAfter()` sets the `Retry-After` header of the response
+// to `aDelay` rounded up to full seconds.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aDelay`: The time the remote host should wait.
+func setRetryAfter(aWriter http.ResponseWriter, aDelay time.Duration) {
+	secs := int64((aDelay + time.Second - 1) / time.Second)
+	if 1 > secs {
+		secs = 1
+	}
+
+	aWriter.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+} // setRetryAfter()
+
+// --------------------------------------------------------------------------
+
+// `contextError()` returns the reason why `aCtx` is done.
+//
+// The returned error matches both the context's own error and
+// either `ErrTimeout` or `ErrCanceled`.
+//
+// Parameters:
+//   - `aCtx`: The context to check.
+//
+// Returns:
+//   - `error`: The reason of the abort, or `nil` if `aCtx` isn't done.
+func contextError(aCtx context.Context) error {
+	err := aCtx.Err()
+	switch {
+	case nil == err:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+} // contextError()
+
+// `isVerifyAbort()` returns whether `aErr` signals a verification
+// that couldn't be completed (as opposed to a failed one).
+//
+// Parameters:
+//   - `aErr`: The error to check.
+//
+// Returns:
+//   - `bool`: `true` for a busy, cancelled or timed out verification.
+func isVerifyAbort(aErr error) bool {
+	return errors.Is(aErr, ErrBusy) ||
+		errors.Is(aErr, ErrCanceled) ||
+		errors.Is(aErr, ErrTimeout)
+} // isVerifyAbort()
+
+/* _EoF_ */
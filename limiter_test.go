@@ -0,0 +1,123 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_NewVerifyLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		wait    time.Duration
+		wantNil bool
+	}{
+		{" 1", 2, time.Second, false},
+		{" 2", 0, time.Second, true},
+		{" 3", -1, time.Second, true},
+		{" 4", 1, -time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewVerifyLimiter(tt.max, tt.wait)
+			if (nil == got) != tt.wantNil {
+				t.Errorf("NewVerifyLimiter() = %v, wantNil %v",
+					got, tt.wantNil)
+				return
+			}
+			if (nil != got) && (0 > got.wait) {
+				t.Errorf("NewVerifyLimiter() wait = %v, want >= 0",
+					got.wait)
+			}
+		})
+	}
+} // Test_NewVerifyLimiter()
+
+func Test_TVerifyLimiter_acquire(t *testing.T) {
+	vl := NewVerifyLimiter(1, 10*time.Millisecond)
+
+	if err := vl.acquire(context.Background()); nil != err {
+		t.Fatalf("TVerifyLimiter.acquire() 1st error = '%v'", err)
+	}
+	if err := vl.acquire(context.Background()); ErrBusy != err {
+		t.Errorf("TVerifyLimiter.acquire() 2nd error = '%v', want '%v'",
+			err, ErrBusy)
+	}
+	vl.release()
+	if err := vl.acquire(context.Background()); nil != err {
+		t.Errorf("TVerifyLimiter.acquire() 3rd error = '%v'", err)
+	}
+	vl.release()
+
+	var nl *TVerifyLimiter
+	if err := nl.acquire(context.Background()); nil != err {
+		t.Errorf("TVerifyLimiter.acquire() nil limiter error = '%v'", err)
+	}
+	nl.release()
+} // Test_TVerifyLimiter_acquire()
+
+func Test_TVerifyLimiter_RetryAfter(t *testing.T) {
+	tests := []struct {
+		name string
+		vl   *TVerifyLimiter
+		want time.Duration
+	}{
+		{" 1", nil, time.Second},
+		{" 2", NewVerifyLimiter(1, 0), time.Second},
+		{" 3", NewVerifyLimiter(1, 3*time.Second), 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vl.RetryAfter(); got != tt.want {
+				t.Errorf("TVerifyLimiter.RetryAfter() = %v, want %v",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_TVerifyLimiter_RetryAfter()
+
+func Test_Wrap_busy(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	_, _ = ul.Store()
+	defer func() {
+		_ = os.Remove(ul.filename)
+		SetVerifyLimit(0, 0)
+	}()
+
+	SetVerifyLimit(1, 0)
+	vl := pwLimiter.Load()
+	_ = vl.acquire(context.Background()) // occupy the only slot
+	defer vl.release()
+
+	next := http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		aWriter.WriteHeader(http.StatusOK)
+	})
+	handler := Wrap(next, "test", ul.filename, TAuthNeeder{})
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.SetBasicAuth(u1, p1)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if http.StatusServiceUnavailable != rec.Code {
+		t.Errorf("Wrap() status = %d, want %d",
+			rec.Code, http.StatusServiceUnavailable)
+	}
+	if "1" != rec.Header().Get("Retry-After") {
+		t.Errorf("Wrap() Retry-After = %q, want %q",
+			rec.Header().Get("Retry-After"), "1")
+	}
+} // Test_Wrap_busy()
+
+/* _EoF_ */
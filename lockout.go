@@ -0,0 +1,498 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the protection against brute-force attacks
+ * by counting failed authentications per user and client address.
+ */
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// Key prefix of per-user failure counters.
+	lockUserPrefix = "user:"
+
+	// Key prefix of per-address failure counters.
+	lockIPPrefix = "ip:"
+
+	// Default max. number of tracked usernames.
+	lockMaxUsers = 10000
+
+	// Default max. number of tracked client addresses.
+	lockMaxIPs = 10000
+)
+
+var (
+	// `ErrLocked` is passed to [THooks.OnFailure] if a request was
+	// refused because of too many failed authentications.
+	ErrLocked = errors.New("too many failed authentications")
+)
+
+type (
+	// `tFailures` counts the failed authentications of a single
+	// user or client address.
+	tFailures struct {
+		count int       // number of failures
+		last  time.Time // time of the latest failure
+		until time.Time // end of the current lockout
+	}
+
+	// `TLockInfo` describes the state of a failure counter.
+	TLockInfo struct {
+		Key      string    // "user:<name>", "ip:<address>", or "ip:<IPv6 /64 network>"
+		Failures int       // number of failed authentications
+		Until    time.Time // end of the current lockout (if any)
+	}
+
+	// `TLockout` counts failed authentications per username and per
+	// client address, locking out further attempts for a growing
+	// time once a configured number of failures is reached.
+	//
+	// Counters expire automatically if there's no further failure
+	// within the configured expiry time.
+	//
+	// The number of tracked usernames is limited (see
+	// [TLockout.SetMaxUsers]) so that an attacker rotating random
+	// usernames can't grow the memory without bounds; once the limit
+	// is reached failures of further usernames are counted for the
+	// client address only. The number of tracked client addresses is
+	// limited as well (see [TLockout.SetMaxIPs]), dropping the oldest
+	// counter for a new one; IPv6 clients are counted by their /64
+	// network since a single host usually owns a whole one.
+	//
+	// NOTE: Locking out usernames allows an attacker to lock out
+	// legitimate users by sending wrong passwords for their names.
+	// Keep the delays moderate.
+	TLockout struct {
+		mtx      sync.Mutex            // protect concurrent access
+		counters map[string]*tFailures // failure counters by key
+		proxies  *TTrustedProxies      // proxies allowed to forward addresses
+		users    int                   // number of per-user counters
+		maxUsers int                   // max. number of per-user counters
+		ips      int                   // number of per-address counters
+		maxIPs   int                   // max. number of per-address counters
+		maxFails int                   // failures before locking out
+		delay    time.Duration         // initial lockout time
+		maxDelay time.Duration         // max. lockout time
+		expiry   time.Duration         // quiet time to reset a counter
+		swept    time.Time             // time of the latest cleanup
+	}
+)
+
+// `NewLockout()` returns a new brute-force protection.
+//
+// After `aMaxFailures` failed authentications for the same username
+// or client address further attempts are refused for `aDelay`; each
+// further failure doubles that time up to `aMaxDelay`. If `aDelay`
+// equals `aMaxDelay` this results in a fixed temporary lockout.
+// A counter is dropped once there was no failure for `aExpiry`.
+//
+// Parameters:
+//   - `aMaxFailures`: The number of failures before locking out.
+//   - `aDelay`: The initial lockout time.
+//   - `aMaxDelay`: The max. lockout time.
+//   - `aExpiry`: The quiet time after which a counter is dropped.
+//
+// Returns:
+//   - `*TLockout`: The new brute-force protection.
+func NewLockout(aMaxFailures int, aDelay, aMaxDelay, aExpiry time.Duration) *TLockout {
+	if 1 > aMaxFailures {
+		aMaxFailures = 1
+	}
+	if 0 >= aDelay {
+		aDelay = time.Second
+	}
+	if aMaxDelay < aDelay {
+		aMaxDelay = aDelay
+	}
+	if aExpiry < aMaxDelay {
+		aExpiry = aMaxDelay
+	}
+
+	return &TLockout{
+		counters: make(map[string]*tFailures, 64),
+		maxUsers: lockMaxUsers,
+		maxIPs:   lockMaxIPs,
+		maxFails: aMaxFailures,
+		delay:    aDelay,
+		maxDelay: aMaxDelay,
+		expiry:   aExpiry,
+		swept:    time.Now(),
+	}
+} // NewLockout()
+
+// --------------------------------------------------------------------------
+// `TLockout` methods:
+
+// `Clear()` removes the counter identified by `aKey` (as returned in
+// [TLockInfo.Key]).
+//
+// Parameters:
+//   - `aKey`: The key of the counter to remove.
+func (lo *TLockout) Clear(aKey string) {
+	lo.mtx.Lock()
+	lo.drop(aKey)
+	lo.mtx.Unlock()
+} // Clear()
+
+// `ClearAll()` removes all counters.
+func (lo *TLockout) ClearAll() {
+	lo.mtx.Lock()
+	clear(lo.counters)
+	lo.users, lo.ips = 0, 0
+	lo.mtx.Unlock()
+} // ClearAll()
+
+// `ClearIP()` removes the counter of the client address `aIP`.
+//
+// Parameters:
+//   - `aIP`: The client address whose counter to remove.
+func (lo *TLockout) ClearIP(aIP string) {
+	if addr := parseAddr(aIP); addr.IsValid() {
+		lo.Clear(lockIPPrefix + lockAddr(addr.String()))
+	}
+} // ClearIP()
+
+// `ClearUser()` removes the counter of `aUser`.
+//
+// Parameters:
+//   - `aUser`: The user whose counter to remove.
+func (lo *TLockout) ClearUser(aUser string) {
+	lo.Clear(lockUserPrefix + strings.TrimSpace(aUser))
+} // ClearUser()
+
+// `ClientIP()` returns the client address of `aRequest` using the
+// configured trusted proxies.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `string`: The client's address (empty if unknown).
+func (lo *TLockout) ClientIP(aRequest *http.Request) string {
+	if addr := lo.proxies.ClientIP(aRequest); addr.IsValid() {
+		return addr.String()
+	}
+
+	return ""
+} // ClientIP()
+
+// `Delay()` returns the remaining lockout time for `aUser` and
+// the client address `aIP`.
+//
+// Parameters:
+//   - `aUser`: The username to check (may be empty).
+//   - `aIP`: The client address to check (may be empty).
+//
+// Returns:
+//   - `time.Duration`: The remaining lockout time, or zero.
+func (lo *TLockout) Delay(aUser, aIP string) time.Duration {
+	result, _ := lo.lockState(aUser, aIP)
+
+	return result
+} // Delay()
+
+// `drop()` removes the counter identified by `aKey`.
+//
+// NOTE: The caller must hold the lock.
+//
+// Parameters:
+//   - `aKey`: The key of the counter to remove.
+func (lo *TLockout) drop(aKey string) {
+	if _, ok := lo.counters[aKey]; !ok {
+		return
+	}
+	delete(lo.counters, aKey)
+	if strings.HasPrefix(aKey, lockUserPrefix) {
+		lo.users--
+	} else {
+		lo.ips--
+	}
+} // drop()
+
+// `evictIP()` drops the oldest client address counter, preferring
+// those without a running lockout.
+//
+// NOTE: The caller must hold the lock.
+//
+// Parameters:
+//   - `aNow`: The current time.
+func (lo *TLockout) evictIP(aNow time.Time) {
+	var oldest, oldestFree string
+	for key, f := range lo.counters {
+		if !strings.HasPrefix(key, lockIPPrefix) {
+			continue
+		}
+		if ("" == oldest) || f.last.Before(lo.counters[oldest].last) {
+			oldest = key
+		}
+		if !f.until.After(aNow) &&
+			(("" == oldestFree) || f.last.Before(lo.counters[oldestFree].last)) {
+			oldestFree = key
+		}
+	}
+	if "" != oldestFree {
+		oldest = oldestFree
+	}
+	if "" != oldest {
+		lo.drop(oldest)
+	}
+} // evictIP()
+
+// `Failure()` counts a failed authentication for `aUser` and the
+// client address `aIP`.
+//
+// If the max. number of tracked usernames is reached and `aUser`
+// isn't tracked yet only the client address's failure is counted.
+// If the max. number of tracked addresses is reached the oldest
+// address counter (preferably one without a running lockout) is
+// dropped.
+//
+// Parameters:
+//   - `aUser`: The username used (may be empty).
+//   - `aIP`: The client address used (may be empty).
+func (lo *TLockout) Failure(aUser, aIP string) {
+	now := time.Now()
+
+	lo.mtx.Lock()
+	defer lo.mtx.Unlock()
+
+	lo.sweep(now)
+	for _, key := range lockKeys(aUser, aIP) {
+		f, ok := lo.counters[key]
+		if !ok {
+			if strings.HasPrefix(key, lockUserPrefix) {
+				if lo.users >= lo.maxUsers {
+					continue
+				}
+				lo.users++
+			} else {
+				if lo.ips >= lo.maxIPs {
+					lo.evictIP(now)
+				}
+				lo.ips++
+			}
+			f = &tFailures{}
+			lo.counters[key] = f
+		} else if now.Sub(f.last) > lo.expiry {
+			*f = tFailures{}
+		}
+		f.count++
+		f.last = now
+
+		if f.count >= lo.maxFails {
+			wait := lo.delay
+			for n := f.count - lo.maxFails; (0 < n) && (wait < lo.maxDelay); n-- {
+				wait *= 2
+			}
+			f.until = now.Add(min(wait, lo.maxDelay))
+		}
+	}
+} // Failure()
+
+// `Locked()` returns the state of all counters with a running
+// lockout, sorted by key.
+//
+// Returns:
+//   - `[]TLockInfo`: The list of current lockouts.
+func (lo *TLockout) Locked() []TLockInfo {
+	now := time.Now()
+
+	lo.mtx.Lock()
+	defer lo.mtx.Unlock()
+
+	result := make([]TLockInfo, 0, len(lo.counters))
+	for key, f := range lo.counters {
+		if f.until.After(now) {
+			result = append(result, TLockInfo{
+				Key:      key,
+				Failures: f.count,
+				Until:    f.until,
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b TLockInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return result
+} // Locked()
+
+// `lockState()` returns the time to wait before `aUser` may try again
+// from `aIP`, and whether the user's own counter is locked (as
+// opposed to just the client address).
+//
+// Parameters:
+//   - `aUser`: The username to check (may be empty).
+//   - `aIP`: The client address to check (may be empty).
+//
+// Returns:
+//   - `time.Duration`: The remaining lockout time (zero if not locked).
+//   - `bool`: `true` if the username itself is locked.
+func (lo *TLockout) lockState(aUser, aIP string) (time.Duration, bool) {
+	now := time.Now()
+
+	lo.mtx.Lock()
+	defer lo.mtx.Unlock()
+
+	var (
+		result time.Duration
+		user   bool
+	)
+	for _, key := range lockKeys(aUser, aIP) {
+		if f, ok := lo.counters[key]; ok {
+			wait := f.until.Sub(now)
+			if 0 < wait && strings.HasPrefix(key, lockUserPrefix) {
+				user = true
+			}
+			if wait > result {
+				result = wait
+			}
+		}
+	}
+
+	return result, user
+} // lockState()
+
+// `SetMaxIPs()` sets the max. number of client addresses whose
+// failures are tracked at the same time.
+//
+// Parameters:
+//   - `aMax`: The max. number of tracked addresses (default: 10000).
+//
+// Returns:
+//   - `*TLockout`: The updated instance.
+func (lo *TLockout) SetMaxIPs(aMax int) *TLockout {
+	if 0 >= aMax {
+		aMax = lockMaxIPs
+	}
+	lo.mtx.Lock()
+	lo.maxIPs = aMax
+	lo.mtx.Unlock()
+
+	return lo
+} // SetMaxIPs()
+
+// `SetMaxUsers()` sets the max. number of usernames whose failures
+// are tracked at the same time.
+//
+// Parameters:
+//   - `aMax`: The max. number of tracked usernames (default: 10000).
+//
+// Returns:
+//   - `*TLockout`: The updated instance.
+func (lo *TLockout) SetMaxUsers(aMax int) *TLockout {
+	if 0 >= aMax {
+		aMax = lockMaxUsers
+	}
+	lo.mtx.Lock()
+	lo.maxUsers = aMax
+	lo.mtx.Unlock()
+
+	return lo
+} // SetMaxUsers()
+
+// `SetTrustedProxies()` sets the proxies allowed to report the
+// client's address by the `X-Forwarded-For` header.
+//
+// Parameters:
+//   - `aProxies`: The trusted proxies.
+//
+// Returns:
+//   - `*TLockout`: The updated instance.
+func (lo *TLockout) SetTrustedProxies(aProxies *TTrustedProxies) *TLockout {
+	lo.proxies = aProxies
+
+	return lo
+} // SetTrustedProxies()
+
+// `Success()` resets the failure counter of `aUser` after a
+// successful authentication.
+//
+// The client address's counter remains untouched so that an attacker
+// can't reset it by logging in with a valid account of their own.
+//
+// Parameters:
+//   - `aUser`: The successfully authenticated user.
+func (lo *TLockout) Success(aUser string) {
+	lo.ClearUser(aUser)
+} // Success()
+
+// `sweep()` removes all expired counters.
+//
+// NOTE: The caller must hold the lock.
+//
+// Parameters:
+//   - `aNow`: The current time.
+func (lo *TLockout) sweep(aNow time.Time) {
+	if aNow.Sub(lo.swept) < lo.expiry {
+		return
+	}
+	lo.swept = aNow
+
+	for key, f := range lo.counters {
+		if (aNow.Sub(f.last) > lo.expiry) && !f.until.After(aNow) {
+			lo.drop(key)
+		}
+	}
+} // sweep()
+
+// --------------------------------------------------------------------------
+
+// `lockAddr()` returns the part of the client address `aIP` to
+// count failures for: the /64 network of IPv6 addresses, or the
+// address itself otherwise.
+//
+// Parameters:
+//   - `aIP`: The client address.
+//
+// Returns:
+//   - `string`: The address or network to use as counter key.
+func lockAddr(aIP string) string {
+	addr := parseAddr(aIP)
+	if !addr.Is6() {
+		return aIP
+	}
+	prefix, err := addr.Prefix(64)
+	if nil != err {
+		return aIP
+	}
+
+	return prefix.String()
+} // lockAddr()
+
+// `lockKeys()` returns the counter keys for `aUser` and `aIP`.
+//
+// Parameters:
+//   - `aUser`: The username (may be empty).
+//   - `aIP`: The client address (may be empty).
+//
+// Returns:
+//   - `[]string`: The non-empty counter keys.
+func lockKeys(aUser, aIP string) []string {
+	result := make([]string, 0, 2)
+	if aUser = strings.TrimSpace(aUser); "" != aUser {
+		result = append(result, lockUserPrefix+aUser)
+	}
+	if aIP = strings.TrimSpace(aIP); "" != aIP {
+		result = append(result, lockIPPrefix+lockAddr(aIP))
+	}
+
+	return result
+} // lockKeys()
+
+/* _EoF_ */
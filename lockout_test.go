@@ -0,0 +1,191 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TLockout_Failure(t *testing.T) {
+	lo := NewLockout(2, time.Minute, 4*time.Minute, time.Hour)
+	u1, ip1 := "username1", "192.0.2.1"
+
+	tests := []struct {
+		name    string
+		minWait time.Duration
+		maxWait time.Duration
+	}{
+		{" 1", 0, 0},
+		{" 2", 59 * time.Second, time.Minute},
+		{" 3", 119 * time.Second, 2 * time.Minute},
+		{" 4", 239 * time.Second, 4 * time.Minute},
+		{" 5", 239 * time.Second, 4 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lo.Failure(u1, ip1)
+			got := lo.Delay(u1, "")
+			if (got < tt.minWait) || (got > tt.maxWait) {
+				t.Errorf("TLockout.Delay() = %v, want %v..%v",
+					got, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+
+	if got := lo.Delay("", ip1); 239*time.Second > got {
+		t.Errorf("TLockout.Delay(ip) = %v, want ~%v", got, 4*time.Minute)
+	}
+	if got := len(lo.Locked()); 2 != got {
+		t.Errorf("TLockout.Locked() = %d entries, want %d", got, 2)
+	}
+
+	// A success resets the user but not the address:
+	lo.Success(u1)
+	if got := lo.Delay(u1, ""); 0 != got {
+		t.Errorf("TLockout.Delay(user) = %v, want %v", got, 0)
+	}
+	if got := lo.Delay(u1, ip1); 0 == got {
+		t.Error("TLockout.Delay(user, ip) = 0, want > 0")
+	}
+
+	lo.ClearIP(ip1)
+	if got := lo.Locked(); 0 != len(got) {
+		t.Errorf("TLockout.Locked() = %v, want none", got)
+	}
+} // Test_TLockout_Failure()
+
+func Test_TLockout_expiry(t *testing.T) {
+	lo := NewLockout(1, time.Millisecond, time.Millisecond, time.Millisecond)
+	lo.Failure("username1", "")
+	if 0 == lo.Delay("username1", "") {
+		t.Error("TLockout.Delay() = 0, want > 0")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if got := lo.Delay("username1", ""); 0 != got {
+		t.Errorf("TLockout.Delay() = %v, want %v", got, 0)
+	}
+
+	// The next failure sweeps the expired counter:
+	lo.Failure("", "192.0.2.1")
+	lo.mtx.Lock()
+	_, ok := lo.counters[lockUserPrefix+"username1"]
+	lo.mtx.Unlock()
+	if ok {
+		t.Error("TLockout.sweep() kept an expired counter")
+	}
+
+	lo.ClearAll()
+	if got := lo.Locked(); 0 != len(got) {
+		t.Errorf("TLockout.Locked() = %v, want none", got)
+	}
+} // Test_TLockout_expiry()
+
+func Test_TLockout_maxUsers(t *testing.T) {
+	lo := NewLockout(1, time.Minute, time.Minute, time.Hour).SetMaxUsers(2)
+	ip1 := "192.0.2.1"
+	for _, user := range []string{"username1", "username2", "username3", "username4"} {
+		lo.Failure(user, ip1)
+	}
+
+	lo.mtx.Lock()
+	got, users := len(lo.counters), lo.users
+	lo.mtx.Unlock()
+	if (3 != got) || (2 != users) {
+		t.Errorf("TLockout counters = %d (users %d), want %d (users %d)",
+			got, users, 3, 2)
+	}
+	if 0 == lo.Delay("username2", "") {
+		t.Error("TLockout.Delay(tracked user) = 0, want > 0")
+	}
+	if got := lo.Delay("username3", ""); 0 != got {
+		t.Errorf("TLockout.Delay(untracked user) = %v, want %v", got, 0)
+	}
+	if 0 == lo.Delay("", ip1) {
+		t.Error("TLockout.Delay(ip) = 0, want > 0")
+	}
+
+	// Removing a user makes room for another one:
+	lo.ClearUser("username1")
+	lo.Failure("username3", "")
+	if 0 == lo.Delay("username3", "") {
+		t.Error("TLockout.Delay(username3) = 0, want > 0")
+	}
+} // Test_TLockout_maxUsers()
+
+func Test_TLockout_maxIPs(t *testing.T) {
+	lo := NewLockout(1, time.Minute, time.Minute, time.Hour).SetMaxIPs(2)
+	ips := []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}
+	for _, ip := range ips {
+		lo.Failure("", ip)
+		time.Sleep(time.Millisecond) // distinct failure times
+	}
+
+	lo.mtx.Lock()
+	got, addrs := len(lo.counters), lo.ips
+	lo.mtx.Unlock()
+	if (2 != got) || (2 != addrs) {
+		t.Errorf("TLockout counters = %d (ips %d), want %d (ips %d)",
+			got, addrs, 2, 2)
+	}
+	// all addresses are locked, so the oldest ones were dropped:
+	for i, ip := range ips {
+		if locked := (0 < lo.Delay("", ip)); locked != (1 < i) {
+			t.Errorf("TLockout.Delay(%s) locked = %v, want %v", ip, locked, 1 < i)
+		}
+	}
+
+	// a counter without lockout is dropped first:
+	lo = NewLockout(2, time.Minute, time.Minute, time.Hour).SetMaxIPs(2)
+	lo.Failure("", ips[0])
+	lo.Failure("", ips[0]) // locked
+	lo.Failure("", ips[1]) // not locked
+	lo.Failure("", ips[2])
+	if 0 == lo.Delay("", ips[0]) {
+		t.Errorf("TLockout.Delay(%s) = 0, want > 0", ips[0])
+	}
+	lo.mtx.Lock()
+	_, ok := lo.counters[lockIPPrefix+ips[1]]
+	lo.mtx.Unlock()
+	if ok {
+		t.Errorf("TLockout kept the counter of %s", ips[1])
+	}
+} // Test_TLockout_maxIPs()
+
+func Test_lockAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{" 1", "192.0.2.1", "192.0.2.1"},
+		{" 2", "2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{" 3", "2001:db8:1:2::ffff", "2001:db8:1:2::/64"},
+		{" 4", "unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockAddr(tt.ip); got != tt.want {
+				t.Errorf("lockAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// all addresses of a /64 network share a counter:
+	lo := NewLockout(1, time.Minute, time.Minute, time.Hour)
+	lo.Failure("", "2001:db8:1:2::1")
+	if 0 == lo.Delay("", "2001:db8:1:2::2") {
+		t.Error("TLockout.Delay(same /64) = 0, want > 0")
+	}
+	lo.ClearIP("2001:db8:1:2::3")
+	if got := lo.Locked(); 0 != len(got) {
+		t.Errorf("TLockout.Locked() = %v, want none", got)
+	}
+} // Test_lockAddr()
+
+/* _EoF_ */
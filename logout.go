@@ -0,0 +1,128 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the logout of users authenticated by the
+ * HTTP Basic or Digest authentication.
+ */
+
+import (
+	"errors"
+	"net/http"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `LogoutCookie` is the name of the cookie marking a remote
+	// host as logged out.
+	LogoutCookie = "passlist_logout"
+)
+
+var (
+	// `ErrLoggedOut` is passed to [THooks.OnFailure] if a request was
+	// refused because the remote host sent its cached credentials
+	// after a logout.
+	ErrLoggedOut = errors.New("credentials sent after logout")
+)
+
+// `Logout()` returns a handler logging out the remote user.
+//
+// Browsers cache the credentials of the HTTP Basic and Digest
+// authentication and send them with each request until the browser
+// is closed; there's no way for a server to delete them. Therefore
+// the handler sets a cookie marking the remote host as logged out.
+// The next request still carrying credentials is answered with
+// "401 Unauthorised" and a fresh challenge (removing the marker),
+// which makes the browsers discard the rejected credentials and ask
+// the user for new ones. If the user cancels that dialogue the
+// browser remains logged out.
+//
+// A session cookie (see [WithSessions]) is revoked and removed as well.
+//
+// The handler itself doesn't need authentication; if `aTarget` is
+// empty it answers with a short plain text notice, otherwise it
+// redirects the remote host to `aTarget`.
+//
+// To keep third-party pages from logging out users (e.g. by an
+// `<img src="/logout">`) only `POST` requests are accepted (others
+// get "405 Method Not Allowed"), and requests a browser marks as
+// cross-site by the `Sec-Fetch-Site` header are refused with "403
+// Forbidden". So call the handler by a form like
+// `<form method="post" action="/logout">`.
+//
+// Parameters:
+//   - `aTarget`: The URL to redirect to after logging out (may be empty).
+//
+// Returns:
+//   - `http.Handler`: The logout handler.
+func (mw *TMiddleware) Logout(aTarget string) http.Handler {
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		if http.MethodPost != aRequest.Method {
+			aWriter.Header().Set("Allow", http.MethodPost)
+			mw.deny(aWriter, aRequest, http.StatusMethodNotAllowed)
+			return
+		}
+		if "cross-site" == aRequest.Header.Get("Sec-Fetch-Site") {
+			mw.deny(aWriter, aRequest, http.StatusForbidden)
+			return
+		}
+		if nil != mw.sessions {
+			mw.sessions.Revoke(aRequest)
+			mw.sessions.Clear(aWriter)
+		}
+		setLogoutCookie(aWriter, 0)
+		aWriter.Header().Set("Cache-Control", "no-store")
+
+		if "" != aTarget {
+			http.Redirect(aWriter, aRequest, aTarget, http.StatusSeeOther)
+			return
+		}
+		aWriter.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		aWriter.WriteHeader(http.StatusOK)
+		_, _ = aWriter.Write([]byte("logged out\n"))
+	})
+} // Logout()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `isLoggedOut()` returns whether `aRequest` carries the logout marker.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//
+// Returns:
+//   - `bool`: `true` if the remote host was logged out.
+func isLoggedOut(aRequest *http.Request) bool {
+	_, err := aRequest.Cookie(LogoutCookie)
+
+	return nil == err
+} // isLoggedOut()
+
+// `setLogoutCookie()` sets (or, with a negative `aMaxAge`, removes)
+// the logout marker.
+//
+// The marker isn't confidential at all (forging it would just log
+// out oneself), so it's sent over unencrypted connections as well.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aMaxAge`: The cookie's max. age (zero for a browser session).
+func setLogoutCookie(aWriter http.ResponseWriter, aMaxAge int) {
+	http.SetCookie(aWriter, &http.Cookie{
+		Name:     LogoutCookie,
+		Value:    "1",
+		Path:     "/",
+		MaxAge:   aMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+} // setLogoutCookie()
+
+/* _EoF_ */
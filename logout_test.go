@@ -0,0 +1,119 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_TMiddleware_Logout(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	var lastErr error
+	mw := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithSessions(NewSessions(time.Minute, time.Hour)),
+		WithHooks(THooks{
+			OnFailure: func(aRequest *http.Request, aErr error) {
+				lastErr = aErr
+			},
+		}),
+		WithLogger(quietLogger),
+	)
+	handler := mw.Wrap(okHandler)
+
+	// log in and get a session cookie
+	rec := serve(handler, u1, p1)
+	session := rec.Result().Cookies()[0]
+
+	// only same-site POST requests log out
+	for _, bad := range []struct {
+		method, site string
+		want         int
+	}{
+		{"GET", "", http.StatusMethodNotAllowed},
+		{"POST", "cross-site", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(bad.method, "http://example.com/logout", nil)
+		if "" != bad.site {
+			req.Header.Set("Sec-Fetch-Site", bad.site)
+		}
+		rec = httptest.NewRecorder()
+		mw.Logout("/").ServeHTTP(rec, req)
+		if (bad.want != rec.Code) || (0 != len(rec.Result().Cookies())) {
+			t.Errorf("TMiddleware.Logout(%s %s) status = %d, cookies = %v, want %d",
+				bad.method, bad.site, rec.Code, rec.Result().Cookies(), bad.want)
+		}
+	}
+
+	// log out
+	req := httptest.NewRequest("POST", "http://example.com/logout", nil)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	mw.Logout("/").ServeHTTP(rec, req)
+	if http.StatusSeeOther != rec.Code {
+		t.Errorf("TMiddleware.Logout() status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	var marker *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		switch cookie.Name {
+		case LogoutCookie:
+			marker = cookie
+		case session.Name:
+			if 0 <= cookie.MaxAge {
+				t.Errorf("TMiddleware.Logout() kept the session cookie: %v", cookie)
+			}
+		}
+	}
+	if nil == marker {
+		t.Fatal("TMiddleware.Logout() didn't set the logout marker")
+	}
+
+	// the revoked session isn't accepted anymore
+	req = httptest.NewRequest("GET", "http://example.com/", nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if http.StatusUnauthorized != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// the cached credentials are rejected once ...
+	req = httptest.NewRequest("GET", "http://example.com/", nil)
+	req.SetBasicAuth(u1, p1)
+	req.AddCookie(marker)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if (http.StatusUnauthorized != rec.Code) || !errors.Is(lastErr, ErrLoggedOut) {
+		t.Errorf("TMiddleware.Wrap() status = %d, error = %v", rec.Code, lastErr)
+	}
+	if "" == rec.Header().Get("WWW-Authenticate") {
+		t.Error("TMiddleware.Wrap() missing WWW-Authenticate header")
+	}
+	cleared := false
+	for _, cookie := range rec.Result().Cookies() {
+		if (LogoutCookie == cookie.Name) && (0 > cookie.MaxAge) {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("TMiddleware.Wrap() didn't remove the logout marker")
+	}
+
+	// ... and accepted again without the marker
+	if rec = serve(handler, u1, p1); http.StatusOK != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+} // Test_TMiddleware_Logout()
+
+/* _EoF_ */
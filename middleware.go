@@ -0,0 +1,1243 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the configurable authentication middleware.
+ */
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	se "github.com/mwat56/sourceerror"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+type (
+	// `IUserList` is the interface of user lists the middleware
+	// can use to authenticate requests.
+	//
+	// Both [TPassList] and [TIndexedList] implement this interface.
+	IUserList interface {
+		// `AuthenticateContext()` checks `aRequest` for valid
+		// authentication data.
+		AuthenticateContext(aCtx context.Context, aRequest *http.Request) error
+
+		// `AuthenticateProxyContext()` checks `aRequest` for valid
+		// proxy authentication data.
+		AuthenticateProxyContext(aCtx context.Context, aRequest *http.Request) error
+
+		// `Exists()` returns whether `aUser` is a known user.
+		Exists(aUser string) bool
+
+		// `Find()` returns the stored password hash of `aUser`.
+		Find(aUser string) (string, error)
+
+		// `MatchesContext()` checks the given user/password pair.
+		MatchesContext(aCtx context.Context, aUser, aPassword string) (bool, error)
+	}
+
+	// `TDenyHandler` is called by the middleware to send a response
+	// for a request that didn't pass the authentication.
+	//
+	// All necessary headers (like `WWW-Authenticate` or `Retry-After`)
+	// are already set when the handler is called; it just has to
+	// write the response body using `aStatus` as HTTP status code.
+	TDenyHandler func(aWriter http.ResponseWriter, aRequest *http.Request, aStatus int)
+
+	// `THooks` holds optional functions called by the middleware
+	// after each authentication attempt.
+	THooks struct {
+		// `OnFailure` is called after a failed authentication.
+		OnFailure func(aRequest *http.Request, aErr error)
+
+		// `OnSuccess` is called after a successful authentication.
+		OnSuccess func(aRequest *http.Request, aUser string)
+	}
+
+	// `TOption` is a function configuring a [TMiddleware] instance.
+	TOption func(aMiddleware *TMiddleware)
+
+	// `TMiddleware` is a configurable authentication middleware
+	// created by [NewMiddleware].
+	TMiddleware struct {
+		mtx       sync.RWMutex    // protect list reloading
+		apiKeys   *TAPIKeys       // optional API keys
+		keyHeader string          // optional header carrying API keys
+		list      IUserList       // the user list to use
+		filename  string          // name of the password file to use
+		loadErr   error           // result of the last (re-)load
+		modTime   time.Time       // modification time of the loaded file
+		checked   time.Time       // time of the last reload check
+		interval  time.Duration   // time between reload checks
+		realm     string          // name of the protected domain
+		decider   IAuthDecider    // decides about the need to authenticate
+		deny      TDenyHandler    // writes the denial responses
+		digest    *TDigestAuth    // optional Digest authentication
+		header    *THeaderAuth    // optional trusted identity header
+		logger    *log.Logger     // logger for configuration problems
+		hooks     THooks          // optional authentication callbacks
+		jwt       *TJWT           // optional JSON Web Tokens
+		lockout   *TLockout       // optional brute-force protection
+		limiter   *TVerifyLimiter // limiter for lists loaded from file
+		cache     *TAuthCache     // cache for lists loaded from file
+		certs     *TCertMap       // optional client certificate mapping
+		chain     *TAuthChain     // optional chain of authenticators
+		chainErr  error           // chain rejected by the configuration
+		roles     TRoleFunc       // optional provider of users' roles
+		sessions  *TSessions      // optional session cookies
+		failOpen  bool            // disable authentication w/o user list
+		proxy     bool            // act as a forward proxy
+		noURLUser bool            // don't set the request's `URL.User`
+	}
+)
+
+// --------------------------------------------------------------------------
+// Option functions:
+
+// `WithAPIKeys()` enables the authentication of machine clients by
+// API keys alongside the password based authentication.
+//
+// The keys are accepted as "Bearer" token in the `Authorization`
+// header and, if `aHeader` isn't empty, in the header of that name
+// (e.g. "X-API-Key"). The principal of a request authenticated by
+// an API key is named after the key's owner and carries the key's
+// scopes; its method is [AuthAPIKey].
+//
+// Parameters:
+//   - `aKeys`: The list of valid API keys.
+//   - `aHeader`: The name of an additional header carrying API keys (may be empty).
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithAPIKeys(aKeys *TAPIKeys, aHeader string) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.apiKeys = aKeys
+		aMiddleware.keyHeader = http.CanonicalHeaderKey(strings.TrimSpace(aHeader))
+	}
+} // WithAPIKeys()
+
+// `WithCache()` sets the cache of verified credentials used by a
+// list loaded from the file given by [WithPasswdFile].
+//
+// Parameters:
+//   - `aCache`: The credentials cache to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithCache(aCache *TAuthCache) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.cache = aCache
+	}
+} // WithCache()
+
+// `WithCertMap()` enables the authentication by TLS client
+// certificates.
+//
+// The client certificate of a request is mapped to a user by
+// `aMap`; that user must exist in the user list as well. Requests
+// with a certificate that isn't mapped to a known user are answered
+// with "403 Forbidden", while requests without a client certificate
+// go through the other authentication methods. The principal's
+// method is [AuthCert].
+//
+// NOTE: Configure the server's `tls.Config.ClientAuth` to verify
+// the client certificates (e.g. `tls.VerifyClientCertIfGiven` with
+// your CA in `ClientCAs`); otherwise only SPKI fingerprint mappings
+// are accepted.
+//
+// Parameters:
+//   - `aMap`: The certificate mapping to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithCertMap(aMap *TCertMap) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.certs = aMap
+	}
+} // WithCertMap()
+
+// `WithChain()` replaces the built-in sequence of authentication
+// schemes by `aChain`.
+//
+// The chain's authenticators are tried in their configured order and
+// a denial advertises all their schemes. Lockout (see [WithLockout]),
+// roles (see [WithRoles]), and hooks apply as usual; if sessions are
+// configured by [WithSessions] a successful Basic authentication
+// issues a session cookie (which is checked by the chain only if it
+// contains a [NewSessionAuthenticator]).
+//
+// NOTE: The chain isn't supported in proxy mode (see [WithProxy]);
+// if both are set [NewMiddleware] logs a warning and the password
+// list is used only, while [LoadMiddleware] returns an error.
+//
+// Parameters:
+//   - `aChain`: The chain of authenticators to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithChain(aChain *TAuthChain) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.chain = aChain
+	}
+} // WithChain()
+
+// `WithDecider()` sets the decider whether a request needs to be
+// authenticated.
+//
+// Parameters:
+//   - `aDecider`: The decider to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithDecider(aDecider IAuthDecider) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.decider = aDecider
+	}
+} // WithDecider()
+
+// `WithDenyHandler()` sets the function writing the responses for
+// requests that didn't pass the authentication.
+//
+// If `aHandler` is `nil` a plain text response is sent.
+//
+// Parameters:
+//   - `aHandler`: The deny handler to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithDenyHandler(aHandler TDenyHandler) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.deny = aHandler
+	}
+} // WithDenyHandler()
+
+// `WithDigest()` enables the HTTP Digest authentication alongside
+// the Basic authentication.
+//
+// Requests carrying Digest credentials are checked by `aDigest`
+// while all others are checked by the user list. Denied requests
+// get challenges for both schemes.
+//
+// Parameters:
+//   - `aDigest`: The Digest authenticator to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithDigest(aDigest *TDigestAuth) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.digest = aDigest
+	}
+} // WithDigest()
+
+// `WithFailOpen()` decides what to do if there's no valid user list
+// or no `IAuthDecider`.
+//
+// By default the middleware fails closed, i.e. it denies all requests
+// needing authentication until a valid user list is available. If
+// `aFailOpen` is `true` the authentication is disabled instead (which
+// was the historical behaviour of [Wrap]).
+//
+// Parameters:
+//   - `aFailOpen`: Whether to disable authentication on problems.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithFailOpen(aFailOpen bool) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.failOpen = aFailOpen
+	}
+} // WithFailOpen()
+
+// `WithHeaderAuth()` enables the authentication by an identity
+// header set by a trusted upstream proxy (e.g. an SSO-enabled
+// ingress).
+//
+// Requests sent by one of the authenticator's trusted proxies and
+// carrying the identity header are accepted without any further
+// check (except for the user's existence if configured by
+// [THeaderAuth.SetRequireUser]; unknown users are answered with
+// "403 Forbidden"). All other requests go through the other
+// authentication methods, and their identity header is removed.
+// The principal's method is [AuthHeader].
+//
+// Parameters:
+//   - `aAuth`: The identity header authenticator to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithHeaderAuth(aAuth *THeaderAuth) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.header = aAuth
+	}
+} // WithHeaderAuth()
+
+// `WithHooks()` sets the functions called after each authentication
+// attempt.
+//
+// Parameters:
+//   - `aHooks`: The callback functions to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithHooks(aHooks THooks) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.hooks = aHooks
+	}
+} // WithHooks()
+
+// `WithJWT()` enables the authentication by JSON Web Tokens issued
+// by [TMiddleware.TokenHandler] (or anyone else holding the keys).
+//
+// The tokens are accepted as "Bearer" token in the `Authorization`
+// header. The principal of a request authenticated by a token is
+// named after the token's subject and carries the token's roles
+// and scopes; its method is [AuthJWT].
+//
+// Parameters:
+//   - `aJWT`: The token issuer/validator to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithJWT(aJWT *TJWT) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.jwt = aJWT
+	}
+} // WithJWT()
+
+// `WithLimiter()` sets the limiter of concurrent password verifications
+// used by a list loaded from the file given by [WithPasswdFile].
+//
+// Parameters:
+//   - `aLimiter`: The verification limiter to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithLimiter(aLimiter *TVerifyLimiter) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.limiter = aLimiter
+	}
+} // WithLimiter()
+
+// `WithList()` sets the user list to use for authentication.
+//
+// This option overrides [WithPasswdFile].
+//
+// Parameters:
+//   - `aList`: The user list to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithList(aList IUserList) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.list = aList
+	}
+} // WithList()
+
+// `WithLockout()` sets the brute-force protection to use.
+//
+// Requests of locked out users are answered with "423 Locked",
+// those of locked out client addresses with "429 Too Many Requests";
+// both get a `Retry-After` header and their credentials aren't
+// verified at all.
+//
+// Parameters:
+//   - `aLockout`: The brute-force protection to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithLockout(aLockout *TLockout) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.lockout = aLockout
+	}
+} // WithLockout()
+
+// `WithLogger()` sets the logger used to report problems.
+//
+// If `aLogger` is `nil` the standard logger is used.
+//
+// Parameters:
+//   - `aLogger`: The logger to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithLogger(aLogger *log.Logger) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.logger = aLogger
+	}
+} // WithLogger()
+
+// `WithPasswdFile()` sets the name of the password file to load
+// the user list from.
+//
+// Parameters:
+//   - `aFilename`: The name of the password file to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithPasswdFile(aFilename string) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.filename = strings.TrimSpace(aFilename)
+	}
+} // WithPasswdFile()
+
+// `WithProxy()` switches the middleware to protect a forward proxy.
+//
+// In proxy mode the credentials are read from the request's
+// `Proxy-Authorization` header, denied requests are answered with
+// "407 Proxy Authentication Required" and a `Proxy-Authenticate`
+// header, and the `Proxy-Authorization` header is removed before
+// the request is passed on.
+//
+// Parameters:
+//   - `aEnable`: Whether to act as a forward proxy.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithProxy(aEnable bool) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.proxy = aEnable
+	}
+} // WithProxy()
+
+// `WithRealm()` sets the symbolic name of the domain/host to protect.
+//
+// Parameters:
+//   - `aRealm`: The realm's name.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithRealm(aRealm string) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.realm = strings.TrimSpace(aRealm)
+	}
+} // WithRealm()
+
+// `WithRoles()` sets the function providing the roles of an
+// authenticated user (see [TPrincipal]).
+//
+// Parameters:
+//   - `aRoles`: The function returning a user's roles.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithRoles(aRoles TRoleFunc) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.roles = aRoles
+	}
+} // WithRoles()
+
+// `WithReload()` enables the reloading of the password file given
+// by [WithPasswdFile] whenever it was modified.
+//
+// The file's modification time is checked at most once per
+// `aInterval` while handling requests. If `aInterval` is zero or
+// negative the file is loaded just once.
+//
+// Parameters:
+//   - `aInterval`: The minimal time between two checks.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithReload(aInterval time.Duration) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.interval = aInterval
+	}
+} // WithReload()
+
+// `WithSessions()` enables session cookies.
+//
+// After a successful Basic authentication the remote host gets a
+// signed session cookie; subsequent requests carrying a valid cookie
+// are accepted without verifying the password again.
+//
+// Sessions are ignored in proxy mode (see [WithProxy]) since the
+// cookie would be set for the origin server's domain.
+//
+// Parameters:
+//   - `aSessions`: The session manager to use.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithSessions(aSessions *TSessions) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.sessions = aSessions
+	}
+} // WithSessions()
+
+// `WithURLUser()` decides whether the authenticated user is stored
+// in the request's `URL.User` field as done by [TPassList.IsAuthenticated].
+//
+// That legacy behaviour is enabled by default; only the username is
+// stored there, never the password or its hash. New code should use
+// [PrincipalFromContext] or [UserFromRequest] instead.
+//
+// Parameters:
+//   - `aEnable`: Whether to set the request's `URL.User` field.
+//
+// Returns:
+//   - `TOption`: The configuring function.
+func WithURLUser(aEnable bool) TOption {
+	return func(aMiddleware *TMiddleware) {
+		aMiddleware.noURLUser = !aEnable
+	}
+} // WithURLUser()
+
+// --------------------------------------------------------------------------
+// Constructor functions:
+
+// `LoadMiddleware()` returns a new authentication middleware
+// configured by `aOptions`, or an error if the middleware can't
+// authenticate any request.
+//
+// Other than [NewMiddleware] this function requires both a valid
+// user list and an `IAuthDecider`, thus allowing a program to
+// refuse starting with e.g. a mistyped password file name.
+//
+// Parameters:
+//   - `aOptions`: The options to configure the middleware.
+//
+// Returns:
+//   - `*TMiddleware`: The new middleware instance.
+//   - `error`: A possible error during processing the request.
+func LoadMiddleware(aOptions ...TOption) (*TMiddleware, error) {
+	mw := NewMiddleware(aOptions...)
+
+	if nil != mw.chainErr {
+		return nil, mw.chainErr // already wrapped
+	}
+	if nil == mw.decider {
+		return nil, se.New(errors.New("missing AuthDecider"), 1)
+	}
+	if nil == mw.list {
+		if nil != mw.loadErr {
+			return nil, mw.loadErr // already wrapped
+		}
+		return nil, se.New(errors.New("missing user list"), 1)
+	}
+
+	return mw, nil
+} // LoadMiddleware()
+
+// `NewMiddleware()` returns a new authentication middleware
+// configured by `aOptions`.
+//
+// If the options name a password file (see [WithPasswdFile]) that
+// file is loaded at once; problems doing so are reported by the
+// configured logger. Unless configured by [WithFailOpen] the
+// middleware denies all requests needing authentication while
+// there's no valid user list.
+//
+// Parameters:
+//   - `aOptions`: The options to configure the middleware.
+//
+// Returns:
+//   - `*TMiddleware`: The new middleware instance.
+func NewMiddleware(aOptions ...TOption) *TMiddleware {
+	mw := &TMiddleware{}
+	for _, option := range aOptions {
+		if nil != option {
+			option(mw)
+		}
+	}
+
+	if nil == mw.logger {
+		mw.logger = log.Default()
+	}
+	if nil == mw.deny {
+		mw.deny = denyPlain
+	}
+	if "" == mw.realm {
+		mw.realm = `<unknown>`
+	}
+	if mw.proxy && (nil != mw.chain) {
+		mw.chainErr = se.New(errors.New("authenticator chain not supported in proxy mode"), 1)
+		mw.logger.Printf("passlist.NewMiddleware(): %v\nUSING PASSWORD LIST ONLY!\n", mw.chainErr)
+		mw.chain = nil
+	}
+	if mw.proxy && (nil != mw.sessions) {
+		mw.logger.Printf("passlist.NewMiddleware(): session cookies not supported in proxy mode\nSESSIONS DISABLED!\n")
+		mw.sessions = nil
+	}
+	if nil != mw.list {
+		mw.filename = "" // an explicit list overrides the file
+	} else if "" != mw.filename {
+		mw.checked = time.Now()
+		mw.loadErr = mw.load()
+	}
+
+	return mw
+} // NewMiddleware()
+
+// --------------------------------------------------------------------------
+// `TMiddleware` methods:
+
+// `accept()` finishes the successful authentication of `aRequest`
+// by a method not based on the user list's passwords.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aPrincipal`: The authenticated principal.
+//
+// Returns:
+//   - `*http.Request`: The authenticated request.
+func (mw *TMiddleware) accept(aRequest *http.Request, aPrincipal *TPrincipal) *http.Request {
+	if !mw.noURLUser {
+		aRequest.URL.User = url.User(aPrincipal.Name)
+	}
+	aRequest = aRequest.WithContext(ContextWithPrincipal(aRequest.Context(), aPrincipal))
+
+	if nil != mw.hooks.OnSuccess {
+		mw.hooks.OnSuccess(aRequest, aPrincipal.Name)
+	}
+
+	return aRequest
+} // accept()
+
+// `assignRoles()` sets the roles of `aPrincipal` by the configured
+// role provider unless the principal already carries roles.
+//
+// Parameters:
+//   - `aPrincipal`: The authenticated principal.
+//
+// Returns:
+//   - `*TPrincipal`: The updated principal.
+func (mw *TMiddleware) assignRoles(aPrincipal *TPrincipal) *TPrincipal {
+	if (nil == aPrincipal.Roles) && (nil != mw.roles) {
+		aPrincipal.Roles = mw.roles(aPrincipal.Name)
+	}
+
+	return aPrincipal
+} // assignRoles()
+
+// `authenticate()` checks the credentials of `aRequest`.
+//
+// If the authentication fails a response is sent to the remote host
+// and `nil` is returned. Otherwise the returned request carries the
+// authenticated [TPrincipal] in its context.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aPassword`: Whether to accept password based credentials only (i.e. no API keys, tokens, or sessions).
+//
+// Returns:
+//   - `*http.Request`: The authenticated request, or `nil`.
+func (mw *TMiddleware) authenticate(aWriter http.ResponseWriter, aRequest *http.Request, aPassword bool) *http.Request {
+	header := aRequest.Header.Get(mw.authHeader())
+	if ("" != header) && isLoggedOut(aRequest) {
+		// The browser sent its cached credentials after a logout:
+		// reject them once to make the browser forget them.
+		setLogoutCookie(aWriter, -1)
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, ErrLoggedOut)
+		}
+		mw.denyRequest(aWriter, aRequest, ErrLoggedOut)
+		return nil
+	}
+	if (nil != mw.chain) && !aPassword {
+		return mw.authenticateChain(aWriter, aRequest, header)
+	}
+	if (nil != mw.certs) && !aPassword {
+		user, err := mw.certs.User(aRequest)
+		if "" != user {
+			if list := mw.userList(); (nil == list) || !list.Exists(user) {
+				err = ErrUnmappedCert
+			}
+		}
+		if nil != err {
+			if nil != mw.hooks.OnFailure {
+				mw.hooks.OnFailure(aRequest, err)
+			}
+			mw.deny(aWriter, aRequest, http.StatusForbidden)
+			return nil
+		}
+		if "" != user {
+			p := certPrincipal(user, aRequest.TLS.PeerCertificates[0])
+			return mw.accept(aRequest, mw.assignRoles(p))
+		}
+	}
+	if (nil != mw.header) && !aPassword {
+		user, err := mw.header.User(aRequest)
+		if nil != err {
+			if nil != mw.hooks.OnFailure {
+				mw.hooks.OnFailure(aRequest, err)
+			}
+			mw.deny(aWriter, aRequest, http.StatusForbidden)
+			return nil
+		}
+		if "" != user {
+			return mw.accept(aRequest, mw.principal(user, AuthHeader))
+		}
+	}
+	if (nil != mw.apiKeys) && !aPassword {
+		if key, ok := apiKeyFromRequest(aRequest, mw.authHeader(), mw.keyHeader); ok {
+			return mw.authenticateKey(aWriter, aRequest, key)
+		}
+	}
+	if (nil != mw.jwt) && !aPassword {
+		if token, ok := bearerToken(aRequest, mw.authHeader()); ok && isJWT(token) {
+			return mw.authenticateToken(aWriter, aRequest, token)
+		}
+	}
+
+	method := AuthBasic
+	user, _, _ := parseBasic(header)
+	if (nil != mw.digest) && isDigest(header) {
+		method = AuthDigest
+		if params, ok := parseDigest(header); ok {
+			user = digestUser(params)
+		}
+	}
+
+	if (nil != mw.sessions) && !aPassword {
+		if result := mw.resumeSession(aWriter, aRequest, user); nil != result {
+			return result
+		}
+	}
+
+	var list IUserList
+	if AuthBasic == method {
+		if list = mw.userList(); nil == list {
+			setRetryAfter(aWriter, time.Second)
+			mw.deny(aWriter, aRequest, http.StatusServiceUnavailable)
+			return nil
+		}
+	}
+
+	ip, ok := mw.checkLockout(aWriter, aRequest, user)
+	if !ok {
+		return nil
+	}
+
+	var err error
+	urlUser := aRequest.URL.User
+	switch {
+	case (AuthDigest == method) && mw.proxy:
+		user, err = mw.digest.AuthenticateProxy(aRequest)
+	case AuthDigest == method:
+		user, err = mw.digest.Authenticate(aRequest)
+	case mw.proxy:
+		err = list.AuthenticateProxyContext(aRequest.Context(), aRequest)
+	default:
+		err = list.AuthenticateContext(aRequest.Context(), aRequest)
+	}
+	if nil != err {
+		if (nil != mw.lockout) && errors.Is(err, ErrInvalidCredentials) {
+			mw.lockout.Failure(user, ip)
+		}
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, err)
+		}
+		mw.denyRequest(aWriter, aRequest, err)
+		return nil
+	}
+	if mw.noURLUser {
+		aRequest.URL.User = urlUser
+	}
+	if nil != mw.lockout {
+		mw.lockout.Success(user)
+	}
+	if (nil != mw.sessions) && (AuthBasic == method) && !aPassword {
+		if pwHash, err := list.Find(user); nil == err {
+			mw.sessions.issue(aWriter, user, pwHash)
+		}
+	}
+
+	aRequest = aRequest.WithContext(ContextWithPrincipal(
+		aRequest.Context(), mw.principal(user, method)))
+
+	if nil != mw.hooks.OnSuccess {
+		mw.hooks.OnSuccess(aRequest, user)
+	}
+
+	return aRequest
+} // authenticate()
+
+// `authenticateChain()` checks the credentials of `aRequest` by the
+// configured [TAuthChain].
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aHeader`: The request's `Authorization` header.
+//
+// Returns:
+//   - `*http.Request`: The authenticated request, or `nil` if it was denied.
+func (mw *TMiddleware) authenticateChain(aWriter http.ResponseWriter, aRequest *http.Request, aHeader string) *http.Request {
+	user, _, _ := parseBasic(aHeader)
+	if isDigest(aHeader) {
+		if params, ok := parseDigest(aHeader); ok {
+			user = digestUser(params)
+		}
+	}
+	ip, ok := mw.checkLockout(aWriter, aRequest, user)
+	if !ok {
+		return nil
+	}
+
+	p, auth, err := mw.chain.authenticate(aRequest.Context(), aRequest)
+	if nil != err {
+		if (nil != mw.lockout) && errors.Is(err, ErrInvalidCredentials) {
+			mw.lockout.Failure(user, ip)
+		}
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, err)
+		}
+		if errors.Is(err, ErrUnmappedCert) || errors.Is(err, ErrUnknownUser) {
+			mw.deny(aWriter, aRequest, http.StatusForbidden)
+		} else {
+			mw.denyRequest(aWriter, aRequest, err)
+		}
+		return nil
+	}
+
+	switch p.AuthMethod {
+	case AuthBasic, AuthDigest:
+		if nil != mw.lockout {
+			mw.lockout.Success(p.Name)
+		}
+	}
+	if refresher, ok := auth.(iRefresher); ok {
+		refresher.refresh(aWriter, aRequest)
+	}
+	if (nil != mw.sessions) && (AuthBasic == p.AuthMethod) {
+		if list := mw.userList(); nil != list {
+			if pwHash, err := list.Find(p.Name); nil == err {
+				mw.sessions.issue(aWriter, p.Name, pwHash)
+			}
+		}
+	}
+
+	return mw.accept(aRequest, mw.assignRoles(p))
+} // authenticateChain()
+
+// `authenticateKey()` checks the API key sent with `aRequest`.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aKey`: The API key sent by the remote host.
+//
+// Returns:
+//   - `*http.Request`: The authenticated request, or `nil` if it was denied.
+func (mw *TMiddleware) authenticateKey(aWriter http.ResponseWriter, aRequest *http.Request, aKey string) *http.Request {
+	var ip string
+	if nil != mw.lockout {
+		ip = mw.lockout.ClientIP(aRequest)
+		if wait := mw.lockout.Delay("", ip); 0 < wait {
+			if nil != mw.hooks.OnFailure {
+				mw.hooks.OnFailure(aRequest, ErrLocked)
+			}
+			setRetryAfter(aWriter, wait)
+			mw.deny(aWriter, aRequest, http.StatusTooManyRequests)
+			return nil
+		}
+	}
+
+	key, err := mw.apiKeys.Verify(aKey)
+	if nil != err {
+		if (nil != mw.lockout) && errors.Is(err, ErrInvalidCredentials) {
+			mw.lockout.Failure("", ip)
+		}
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, err)
+		}
+		mw.denyRequest(aWriter, aRequest, err)
+		return nil
+	}
+
+	return mw.accept(aRequest, mw.assignRoles(keyPrincipal(key)))
+} // authenticateKey()
+
+// `authHeader()` returns the name of the request header carrying
+// the credentials.
+//
+// Returns:
+//   - `string`: Either "Authorization" or "Proxy-Authorization".
+func (mw *TMiddleware) authHeader() string {
+	if mw.proxy {
+		return "Proxy-Authorization"
+	}
+
+	return "Authorization"
+} // authHeader()
+
+// `checkLockout()` checks whether `aUser` or the remote host of
+// `aRequest` is locked out (see [WithLockout]).
+//
+// If so, a response is sent to the remote host.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aUser`: The user named in the request's credentials (if any).
+//
+// Returns:
+//   - `string`: The client's IP address (empty w/o lockout).
+//   - `bool`: `true` if the request may proceed, or `false` if it was denied.
+func (mw *TMiddleware) checkLockout(aWriter http.ResponseWriter, aRequest *http.Request, aUser string) (string, bool) {
+	if nil == mw.lockout {
+		return "", true
+	}
+
+	ip := mw.lockout.ClientIP(aRequest)
+	if wait, locked := mw.lockout.lockState(aUser, ip); 0 < wait {
+		if nil != mw.hooks.OnFailure {
+			mw.hooks.OnFailure(aRequest, ErrLocked)
+		}
+		status := http.StatusTooManyRequests
+		if locked {
+			status = http.StatusLocked
+		}
+		setRetryAfter(aWriter, wait)
+		mw.deny(aWriter, aRequest, status)
+		return ip, false
+	}
+
+	return ip, true
+} // checkLockout()
+
+// `denyRequest()` sends a response for a request that didn't pass
+// the authentication.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aErr`: The reason of the denial.
+func (mw *TMiddleware) denyRequest(aWriter http.ResponseWriter, aRequest *http.Request, aErr error) {
+	switch {
+	case errors.Is(aErr, ErrCanceled):
+		// the remote host isn't listening anymore
+		return
+
+	case errors.Is(aErr, ErrBusy), errors.Is(aErr, ErrTimeout):
+		setRetryAfter(aWriter, mw.retryAfter())
+		mw.deny(aWriter, aRequest, http.StatusServiceUnavailable)
+
+	default:
+		header, status := "WWW-Authenticate", http.StatusUnauthorized
+		if mw.proxy {
+			header, status = "Proxy-Authenticate", http.StatusProxyAuthRequired
+		}
+		if nil != mw.chain {
+			for _, challenge := range mw.chain.Challenges(aRequest, mw.realm, aErr) {
+				aWriter.Header().Add(header, challenge)
+			}
+			mw.deny(aWriter, aRequest, status)
+			return
+		}
+		for _, challenge := range mw.digest.Challenges(errors.Is(aErr, ErrStaleNonce)) {
+			aWriter.Header().Add(header, challenge)
+		}
+		if (nil != mw.apiKeys) || (nil != mw.jwt) {
+			_, sent := bearerToken(aRequest, mw.authHeader())
+			aWriter.Header().Add(header, bearerChallenge(mw.realm, sent))
+		}
+		aWriter.Header().Add(header, basicChallenge(mw.realm))
+		mw.deny(aWriter, aRequest, status)
+	}
+} // denyRequest()
+
+// `Forbid()` sends a "403 Forbidden" response using the configured
+// [TDenyHandler].
+//
+// Call it from your handlers if an authenticated user isn't allowed
+// to access the requested resource, so those responses look the same
+// as the middleware's own denials.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+func (mw *TMiddleware) Forbid(aWriter http.ResponseWriter, aRequest *http.Request) {
+	mw.deny(aWriter, aRequest, http.StatusForbidden)
+} // Forbid()
+
+// `load()` reads the password file replacing the current list.
+//
+// NOTE: The caller must hold the write lock (if needed).
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (mw *TMiddleware) load() error {
+	fi, err := os.Stat(mw.filename)
+	if nil != err {
+		return se.New(err, 1)
+	}
+
+	ul := New(mw.filename).SetLimiter(mw.limiter).SetCache(mw.cache)
+	if err = ul.Load(); nil != err {
+		return err // already wrapped
+	}
+	mw.list, mw.modTime = ul, fi.ModTime()
+
+	return nil
+} // load()
+
+// `principal()` returns a new principal for the authenticated `aUser`.
+//
+// Parameters:
+//   - `aUser`: The authenticated user's name.
+//   - `aMethod`: The authentication scheme used.
+//
+// Returns:
+//   - `*TPrincipal`: The new principal.
+func (mw *TMiddleware) principal(aUser, aMethod string) *TPrincipal {
+	return mw.assignRoles(newPrincipal(aUser, aMethod))
+} // principal()
+
+// `Reload()` re-reads the password file given by [WithPasswdFile].
+//
+// If the file can't be read the current list remains in use.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (mw *TMiddleware) Reload() error {
+	if "" == mw.filename {
+		return se.New(errors.New("missing/empty filename"), 1)
+	}
+
+	mw.mtx.Lock()
+	defer mw.mtx.Unlock()
+
+	mw.checked = time.Now()
+	mw.loadErr = mw.load()
+
+	return mw.loadErr
+} // Reload()
+
+// `resumeSession()` checks the session cookie of `aRequest`.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aUser`: The user named in the request's credentials (if any).
+//
+// Returns:
+//   - `*http.Request`: The authenticated request, or `nil` if there's no valid session.
+func (mw *TMiddleware) resumeSession(aWriter http.ResponseWriter, aRequest *http.Request, aUser string) *http.Request {
+	list := mw.userList()
+	if nil == list {
+		return nil
+	}
+	sess, err := mw.sessions.check(aRequest, list.Find)
+	if nil != err {
+		return nil
+	}
+	if ("" != aUser) && (aUser != sess.user) {
+		// The remote host sent credentials of another user.
+		return nil
+	}
+	mw.sessions.refresh(aWriter, sess)
+
+	if !mw.noURLUser {
+		aRequest.URL.User = url.User(sess.user)
+	}
+	aRequest = aRequest.WithContext(ContextWithPrincipal(
+		aRequest.Context(), mw.principal(sess.user, AuthSession)))
+
+	if nil != mw.hooks.OnSuccess {
+		mw.hooks.OnSuccess(aRequest, sess.user)
+	}
+
+	return aRequest
+} // resumeSession()
+
+// `retryAfter()` returns the delay to suggest to a client whose
+// request was rejected by the verification limiter.
+//
+// Returns:
+//   - `time.Duration`: The suggested delay.
+func (mw *TMiddleware) retryAfter() time.Duration {
+	if nil != mw.limiter {
+		return mw.limiter.RetryAfter()
+	}
+
+	return pwLimiter.Load().RetryAfter()
+} // retryAfter()
+
+// `userList()` returns the user list to use, reloading the password
+// file if it was modified since the last check.
+//
+// While there's no valid list at all the password file is checked
+// at least once per second.
+//
+// Returns:
+//   - `IUserList`: The current user list (may be `nil`).
+func (mw *TMiddleware) userList() IUserList {
+	mw.mtx.RLock()
+	list, checked := mw.list, mw.checked
+	mw.mtx.RUnlock()
+
+	if "" == mw.filename {
+		return list
+	}
+	interval := mw.interval
+	if (nil == list) && ((0 >= interval) || (time.Second < interval)) {
+		// retry a missing/broken password file more often
+		interval = time.Second
+	}
+	if (0 >= interval) || (time.Since(checked) < interval) {
+		return list
+	}
+
+	mw.mtx.Lock()
+	defer mw.mtx.Unlock()
+
+	if time.Since(mw.checked) < interval {
+		return mw.list // checked by another request meanwhile
+	}
+	mw.checked = time.Now()
+
+	fi, err := os.Stat(mw.filename)
+	if (nil == err) && (nil != mw.list) && fi.ModTime().Equal(mw.modTime) {
+		return mw.list
+	}
+	if err = mw.load(); nil != err {
+		if nil == mw.loadErr {
+			if nil != mw.list {
+				mw.logger.Printf("passlist.TMiddleware: %v\nkeeping previous user list\n", err)
+			} else {
+				mw.logger.Printf("passlist.TMiddleware: %v\n", err)
+			}
+		}
+	} else if nil != mw.loadErr {
+		mw.logger.Printf("passlist.TMiddleware: user list %q loaded\n", mw.filename)
+	}
+	mw.loadErr = err
+
+	return mw.list
+} // userList()
+
+// `setup()` checks the middleware's configuration when creating
+// a handler, logging any problems.
+//
+// Parameters:
+//   - `aCaller`: The name of the calling method for the log messages.
+//
+// Returns:
+//   - `IAuthDecider`: The decider to use, or `nil` if the authentication is disabled.
+func (mw *TMiddleware) setup(aCaller string) IAuthDecider {
+	decider := mw.decider
+	if nil == decider {
+		if mw.failOpen {
+			mw.logger.Printf("passlist.%s(): missing AuthDecider\nAUTHENTICATION DISABLED!\n", aCaller)
+			// Without a decider we skip the authentication procedure.
+			return nil
+		}
+		mw.logger.Printf("passlist.%s(): missing AuthDecider\nAUTHENTICATING ALL REQUESTS!\n", aCaller)
+		decider = TAuthNeeder{}
+	}
+
+	if (nil == mw.chain) && (nil == mw.userList()) {
+		msg := "missing password file"
+		if nil != mw.loadErr {
+			msg = mw.loadErr.Error()
+		}
+		if mw.failOpen {
+			mw.logger.Printf("passlist.%s(): %s\nAUTHENTICATION DISABLED!\n", aCaller, msg)
+			// We can't do anything w/o password file, so we skip
+			// the whole authentication procedure.
+			return nil
+		}
+		mw.logger.Printf("passlist.%s(): %s\nDENYING ALL PROTECTED REQUESTS!\n", aCaller, msg)
+	}
+
+	return decider
+} // setup()
+
+// `Wrap()` returns a handler that includes authentication, wrapping
+// the given `aNext` and calling it internally.
+//
+// On success the authenticated user is attached as a [TPrincipal]
+// to the context of the request passed to `aNext`.
+//
+// By default the middleware fails closed: without an `IAuthDecider`
+// all requests need authentication, and as long as there's no valid
+// user list all requests needing authentication are answered with
+// "503 Service Unavailable". If the middleware was configured by
+// `WithFailOpen(true)` instead, the authentication is disabled in
+// both cases and `aNext` is returned as is.
+//
+// If the password verification is rejected by the verification
+// limiter or doesn't finish before the request's deadline the remote
+// host gets a "503 Service Unavailable" response with a `Retry-After`
+// header. If the request is cancelled (e.g. the remote host
+// disconnected) nothing is sent at all.
+//
+// In proxy mode (see [WithProxy]) the `Proxy-Authorization` header
+// is removed before `aNext` is called.
+//
+// If a brute-force protection was configured by [WithLockout],
+// requests of locked out users are answered with "423 Locked", those
+// of locked out addresses with "429 Too Many Requests", both with a
+// `Retry-After` header.
+//
+// Parameters:
+//   - `aNext`: The handler to be called after successful authentication.
+//
+// Returns:
+//   - `http.Handler`: The wrapping handler.
+func (mw *TMiddleware) Wrap(aNext http.Handler) http.Handler {
+	decider := mw.setup("Wrap")
+	if nil == decider {
+		// We skip the whole authentication procedure.
+		return aNext
+	}
+
+	newHandler := func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		if nil != mw.header {
+			// Don't let a forged identity header reach `aNext`:
+			mw.header.strip(aRequest)
+		}
+		if decider.NeedAuthentication(aRequest) {
+			if aRequest = mw.authenticate(aWriter, aRequest, false); nil == aRequest {
+				return // response already sent
+			}
+		}
+
+		if mw.proxy {
+			// Don't forward the credentials to the next hop:
+			aRequest.Header.Del("Proxy-Authorization")
+		}
+
+		// Call the previous/original handler:
+		aNext.ServeHTTP(aWriter, aRequest)
+	}
+
+	return http.HandlerFunc(newHandler)
+} // Wrap()
+
+// --------------------------------------------------------------------------
+
+// `denyPlain()` is the default [TDenyHandler] sending a plain text
+// response.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aStatus`: The HTTP status code to send.
+func denyPlain(aWriter http.ResponseWriter, aRequest *http.Request, aStatus int) {
+	text := http.StatusText(aStatus)
+	if http.StatusUnauthorized == aStatus {
+		text = "Unauthorised"
+	}
+
+	http.Error(aWriter, strconv.Itoa(aStatus)+" "+text, aStatus)
+} // denyPlain()
+
+/* _EoF_ */
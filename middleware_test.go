@@ -0,0 +1,344 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// okHandler is an internal test helper answering `200 OK`.
+var okHandler = http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+	aWriter.WriteHeader(http.StatusOK)
+})
+
+// quietLogger is an internal test helper discarding all output.
+var quietLogger = log.New(io.Discard, "", 0)
+
+// serve is an internal test helper returning the status code
+// of `aHandler` serving a GET request with the given credentials.
+func serve(aHandler http.Handler, aUser, aPassword string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	if "" != aUser {
+		req.SetBasicAuth(aUser, aPassword)
+	}
+	rec := httptest.NewRecorder()
+	aHandler.ServeHTTP(rec, req)
+
+	return rec
+} // serve()
+
+func Test_NewMiddleware(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	tests := []struct {
+		name string
+		opts []TOption
+		user string
+		pass string
+		want int
+	}{
+		{" 1", []TOption{WithList(ul), WithDecider(TAuthNeeder{})}, u1, p1, http.StatusOK},
+		{" 2", []TOption{WithList(ul), WithDecider(TAuthNeeder{})}, u1, "wrong", http.StatusUnauthorized},
+		{" 3", []TOption{WithList(ul), WithDecider(TAuthNeeder{})}, "", "", http.StatusUnauthorized},
+		{" 4", []TOption{WithList(ul), WithDecider(TAuthSkipper{})}, "", "", http.StatusOK},
+		{" 5", []TOption{WithList(ul), WithDecider(TAuthNeeder{}),
+			WithDenyHandler(func(aWriter http.ResponseWriter, aRequest *http.Request, aStatus int) {
+				aWriter.WriteHeader(http.StatusTeapot)
+			})}, "", "", http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append(tt.opts, WithLogger(quietLogger))
+			rec := serve(NewMiddleware(opts...).Wrap(okHandler), tt.user, tt.pass)
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+					rec.Code, tt.want)
+			}
+		})
+	}
+} // Test_NewMiddleware()
+
+func Test_TMiddleware_hooks(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	var successes, failures int
+	var lastUser string
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithRealm("test"),
+		WithHooks(THooks{
+			OnFailure: func(aRequest *http.Request, aErr error) {
+				failures++
+			},
+			OnSuccess: func(aRequest *http.Request, aUser string) {
+				successes++
+				lastUser = aUser
+			},
+		}),
+	).Wrap(okHandler)
+
+	_ = serve(handler, u1, p1)
+	rec := serve(handler, u1, "wrong")
+
+	if (1 != successes) || (1 != failures) {
+		t.Errorf("THooks called %d/%d times, want 1/1", successes, failures)
+	}
+	if u1 != lastUser {
+		t.Errorf("THooks.OnSuccess() user = %q, want %q", lastUser, u1)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); `Basic realm="test", charset="UTF-8"` != got {
+		t.Errorf("TMiddleware.Wrap() challenge = %q", got)
+	}
+} // Test_TMiddleware_hooks()
+
+func Test_TMiddleware_lockout(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	var locked int
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithLockout(NewLockout(2, time.Minute, time.Minute, time.Hour)),
+		WithHooks(THooks{
+			OnFailure: func(aRequest *http.Request, aErr error) {
+				if errors.Is(aErr, ErrLocked) {
+					locked++
+				}
+			},
+		}),
+		WithLogger(quietLogger),
+	).Wrap(okHandler)
+
+	tests := []struct {
+		name string
+		user string
+		pass string
+		want int
+	}{
+		{" 1", u1, p1, http.StatusOK},
+		{" 2", u1, "wrong", http.StatusUnauthorized},
+		{" 3", u1, "wrong", http.StatusUnauthorized},
+		{" 4", u1, p1, http.StatusLocked},
+		{" 5", "username2", "wrong", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, tt.user, tt.pass)
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+					rec.Code, tt.want)
+			}
+			if (http.StatusOK != tt.want) && (http.StatusUnauthorized != tt.want) &&
+				("" == rec.Header().Get("Retry-After")) {
+				t.Error("TMiddleware.Wrap() missing Retry-After header")
+			}
+		})
+	}
+	if 2 != locked {
+		t.Errorf("THooks.OnFailure(ErrLocked) calls = %d, want %d", locked, 2)
+	}
+} // Test_TMiddleware_lockout()
+
+func Test_TMiddleware_proxy(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	var forwarded http.Header
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithProxy(true),
+		WithRealm("proxy"),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		forwarded = aRequest.Header.Clone()
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		user   string
+		pass   string
+		want   int
+	}{
+		{" 1", "Proxy-Authorization", u1, p1, http.StatusOK},
+		{" 2", "Proxy-Authorization", u1, "wrong", http.StatusProxyAuthRequired},
+		{" 3", "Authorization", u1, p1, http.StatusProxyAuthRequired},
+		{" 4", "", "", "", http.StatusProxyAuthRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded = nil
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			if "" != tt.header {
+				req.SetBasicAuth(tt.user, tt.pass)
+				req.Header.Set(tt.header, req.Header.Get("Authorization"))
+				if "Authorization" != tt.header {
+					req.Header.Del("Authorization")
+				}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+					rec.Code, tt.want)
+			}
+			if http.StatusOK == tt.want {
+				if "" != forwarded.Get("Proxy-Authorization") {
+					t.Error("TMiddleware.Wrap() forwarded Proxy-Authorization")
+				}
+				return
+			}
+			if got := rec.Header().Get("Proxy-Authenticate"); `Basic realm="proxy", charset="UTF-8"` != got {
+				t.Errorf("TMiddleware.Wrap() Proxy-Authenticate = %q", got)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); "" != got {
+				t.Errorf("TMiddleware.Wrap() WWW-Authenticate = %q", got)
+			}
+		})
+	}
+} // Test_TMiddleware_proxy()
+
+func Test_TMiddleware_reload(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	u2, p2 := "username2", "password2"
+	fn := filepath.Join(t.TempDir(), "passwd.db")
+	ul := New(fn).add0(u1, xxHash(p1))
+	_, _ = ul.Store()
+
+	handler := NewMiddleware(
+		WithPasswdFile(fn),
+		WithDecider(TAuthNeeder{}),
+		WithReload(time.Nanosecond),
+		WithLogger(quietLogger),
+	).Wrap(okHandler)
+
+	if rec := serve(handler, u2, p2); http.StatusUnauthorized != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusUnauthorized)
+	}
+
+	ul.add0(u2, xxHash(p2))
+	_, _ = ul.Store()
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(fn, later, later)
+
+	if rec := serve(handler, u2, p2); http.StatusOK != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusOK)
+	}
+
+	// a broken file keeps the previous list in use
+	_ = os.Remove(fn)
+	if rec := serve(handler, u1, p1); http.StatusOK != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusOK)
+	}
+} // Test_TMiddleware_reload()
+
+func Test_Wrap_disabled(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name    string
+		file    string
+		decider IAuthDecider
+	}{
+		{" 1", "", TAuthNeeder{}},
+		{" 2", "./does-not-exist.db", TAuthNeeder{}},
+		{" 3", "./does-not-exist.db", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(Wrap(okHandler, "test", tt.file, tt.decider), "", "")
+			if http.StatusOK != rec.Code {
+				t.Errorf("Wrap() status = %d, want %d",
+					rec.Code, http.StatusOK)
+			}
+		})
+	}
+} // Test_Wrap_disabled()
+
+func Test_TMiddleware_failClosed(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	fn := filepath.Join(t.TempDir(), "passwd.db")
+
+	handler := NewMiddleware(
+		WithPasswdFile(fn),
+		WithDecider(TAuthNeeder{}),
+		WithLogger(quietLogger),
+	).Wrap(okHandler)
+
+	rec := serve(handler, u1, p1)
+	if http.StatusServiceUnavailable != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusServiceUnavailable)
+	}
+	if "" == rec.Header().Get("Retry-After") {
+		t.Errorf("TMiddleware.Wrap() missing Retry-After header")
+	}
+
+	// the list becomes available later on
+	ul := New(fn).add0(u1, xxHash(p1))
+	_, _ = ul.Store()
+	time.Sleep(time.Second)
+
+	if rec = serve(handler, u1, p1); http.StatusOK != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusOK)
+	}
+
+	// a missing decider protects everything
+	handler = NewMiddleware(WithList(ul), WithLogger(quietLogger)).Wrap(okHandler)
+	if rec = serve(handler, "", ""); http.StatusUnauthorized != rec.Code {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d",
+			rec.Code, http.StatusUnauthorized)
+	}
+} // Test_TMiddleware_failClosed()
+
+func Test_LoadMiddleware(t *testing.T) {
+	ul := prepDB().add0("username1", xxHash("password1"))
+
+	tests := []struct {
+		name    string
+		opts    []TOption
+		wantErr bool
+	}{
+		{" 1", []TOption{WithList(ul), WithDecider(TAuthNeeder{})}, false},
+		{" 2", []TOption{WithList(ul)}, true},
+		{" 3", []TOption{WithPasswdFile("./does-not-exist.db"), WithDecider(TAuthNeeder{})}, true},
+		{" 4", []TOption{WithDecider(TAuthNeeder{})}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadMiddleware(tt.opts...)
+			if (nil != err) != tt.wantErr {
+				t.Errorf("LoadMiddleware() error = '%v', wantErr '%v'",
+					err, tt.wantErr)
+			}
+			if (nil == got) != tt.wantErr {
+				t.Errorf("LoadMiddleware() = %v, wantErr '%v'",
+					got, tt.wantErr)
+			}
+		})
+	}
+} // Test_LoadMiddleware()
+
+/* _EoF_ */
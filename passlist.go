@@ -8,10 +8,9 @@ package passlist
 
 import (
 	"bufio"
+	"context"
 	"errors"
-	"log"
 	"net/http"
-	"net/url"
 	"os"
 	"slices"
 	"strings"
@@ -24,6 +23,9 @@ import (
 
 // `Deny()` sends an "Unauthorised" notice to the remote host.
 //
+// The challenge sent asks for UTF-8 encoded credentials (RFC 7617)
+// with quotes and backslashes in `aRealm` properly escaped.
+//
 // Parameters:
 //   - `aRealm`: The symbolic name of the host/domain to protect.
 //   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
@@ -32,10 +34,25 @@ func Deny(aRealm string, aWriter http.ResponseWriter) {
 		aRealm = "Default"
 	}
 
-	aWriter.Header().Set("WWW-Authenticate", "Basic realm=\""+aRealm+"\"")
+	aWriter.Header().Set("WWW-Authenticate", basicChallenge(aRealm))
 	http.Error(aWriter, "401 Unauthorised", http.StatusUnauthorized)
 } // Deny()
 
+// `DenyProxy()` sends a "Proxy Authentication Required" notice to
+// the remote host of a forward proxy.
+//
+// Parameters:
+//   - `aRealm`: The symbolic name of the proxy to protect.
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+func DenyProxy(aRealm string, aWriter http.ResponseWriter) {
+	if aRealm = strings.TrimSpace(aRealm); "" == aRealm {
+		aRealm = "Default"
+	}
+
+	aWriter.Header().Set("Proxy-Authenticate", basicChallenge(aRealm))
+	http.Error(aWriter, "407 Proxy Authentication Required", http.StatusProxyAuthRequired)
+} // DenyProxy()
+
 // --------------------------------------------------------------------------
 
 const (
@@ -77,8 +94,9 @@ type (
 
 	// `tPassList` is the container for user map and filename.
 	tPassList struct {
-		filename string   // name of passwd file
-		usermap  tUserMap // list of user/password pairs
+		tVerifier          // password verification settings
+		filename  string   // name of passwd file
+		usermap   tUserMap // list of user/password pairs
 	}
 
 	// TPassList holds the list of username/password values.
@@ -199,14 +217,70 @@ func (ul *TPassList) add0(aUser, aHashedPW string) *TPassList {
 	return ul
 } // add0()
 
+// `AuthenticateContext()` checks `aRequest` for authentication data,
+// returning `nil` for successful authentication, or an `error` otherwise.
+//
+// On success the username (but not the password or its hash) is
+// stored in the `aRequest.URL.User` structure to allow for other
+// handlers checking its existence and act accordingly.
+//
+// Unknown users and wrong passwords both result in the same
+// `ErrInvalidCredentials`; to not reveal valid usernames by the
+// response time a password verification is run for unknown users
+// as well.
+//
+// The password verification is abandoned as soon as `aCtx` is
+// cancelled or its deadline expires; in that case the method
+// returns an error matching `ErrCanceled` or `ErrTimeout`
+// respectively. If no verification slot is available (see
+// [TVerifyLimiter]) the method returns `ErrBusy`.
+//
+// If `aRequest` is `nil` the method returns an error.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aRequest` The HTTP request received by a server.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ul *TPassList) AuthenticateContext(aCtx context.Context, aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return ul.tVerifier.authenticate(aCtx, aRequest, "Authorization", ul.Find)
+} // AuthenticateContext()
+
+// `AuthenticateProxyContext()` works like [TPassList.AuthenticateContext]
+// but checks the `Proxy-Authorization` header sent to a forward proxy
+// instead of the `Authorization` header.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aRequest` The HTTP request received by a proxy.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ul *TPassList) AuthenticateProxyContext(aCtx context.Context, aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
+	}
+
+	return ul.tVerifier.authenticate(aCtx, aRequest, "Proxy-Authorization", ul.Find)
+} // AuthenticateProxyContext()
+
 // `Clear()` empties the internal data structure.
 //
+// An optional cache of verified credentials (see [TPassList.SetCache])
+// gets emptied as well.
+//
 // Returns:
 //   - `*TPassList`: The cleaned list.
 func (ul *TPassList) Clear() *TPassList {
 	for user := range ul.usermap {
 		delete(ul.usermap, user)
 	}
+	ul.cache.Clear()
 
 	return ul
 } // Clear()
@@ -256,9 +330,12 @@ func (ul *TPassList) Find(aUser string) (string, error) {
 // `IsAuthenticated()` checks `aRequest` for authentication data,
 // returning `nil` for successful authentication, or an `error` otherwise.
 //
-// On success the username/password are stored in the `aRequest.URL.User`
-// structure to allow for other handlers checking its existence and act
-// accordingly.
+// On success the username (but not the password or its hash) is
+// stored in the `aRequest.URL.User` structure to allow for other
+// handlers checking its existence and act accordingly.
+//
+// The password verification is bound to the context of `aRequest`,
+// see [TPassList.AuthenticateContext] for details.
 //
 // If `aRequest` is `nil` the method returns an error.
 //
@@ -272,25 +349,27 @@ func (ul *TPassList) IsAuthenticated(aRequest *http.Request) error {
 		return se.New(errors.New("missing `aRequest`"), 2)
 	}
 
-	user, pass, ok := aRequest.BasicAuth()
-	if !ok {
-		return se.New(errors.New(`missing authentication data`), 2)
-	}
-
-	pwHash, err := ul.Find(user)
-	if nil != err {
-		return err // already wrapped
-	}
+	return ul.AuthenticateContext(aRequest.Context(), aRequest)
+} // IsAuthenticated()
 
-	if err = bcrypt.CompareHashAndPassword([]byte(pwHash), []byte(pass+pwPepper)); nil != err {
-		return se.New(err, 1)
+// `IsProxyAuthenticated()` checks the `Proxy-Authorization` header of
+// `aRequest`, returning `nil` for successful authentication, or an
+// `error` otherwise.
+//
+// See [TPassList.IsAuthenticated] for details.
+//
+// Parameters:
+//   - `aRequest` The HTTP request received by a proxy.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (ul *TPassList) IsProxyAuthenticated(aRequest *http.Request) error {
+	if nil == aRequest {
+		return se.New(errors.New("missing `aRequest`"), 2)
 	}
 
-	// Store the user info so others can check for it
-	aRequest.URL.User = url.UserPassword(user, pwHash)
-
-	return nil
-} // IsAuthenticated()
+	return ul.AuthenticateProxyContext(aRequest.Context(), aRequest)
+} // IsProxyAuthenticated()
 
 // `Len()` returns the number of entries in the user list.
 //
@@ -346,6 +425,8 @@ func (ul *TPassList) Load() error {
 // user/password pair.
 //
 // If either `aUser` or `aPassword` is empty the method returns `false`.
+// The same is true if no verification slot is available (see
+// [TVerifyLimiter]).
 //
 // Parameters:
 //   - `aUser`: The username to lookup.
@@ -354,23 +435,35 @@ func (ul *TPassList) Load() error {
 // Returns:
 //   - `bool`: `true` if a match was found, or `false` otherwise.
 func (ul *TPassList) Matches(aUser, aPassword string) bool {
-	if aUser = strings.TrimSpace(aUser); "" == aUser {
-		return false
-	}
-	if aPassword = strings.TrimSpace(aPassword); "" == aPassword {
-		return false
-	}
-
-	pwHash, ok := ul.usermap[aUser]
-	if !ok {
-		return false
-	}
-
-	err := bcrypt.CompareHashAndPassword([]byte(pwHash), []byte(aPassword+pwPepper))
+	ok, _ := ul.MatchesContext(context.Background(), aUser, aPassword)
 
-	return (nil == err)
+	return ok
 } // Matches()
 
+// `MatchesContext()` checks whether `aPassword` of `aUser` matches
+// a stored user/password pair.
+//
+// If either `aUser` or `aPassword` is empty, or if `aUser` is unknown,
+// the method returns `false` and `nil`.
+//
+// The password verification is abandoned as soon as `aCtx` is
+// cancelled or its deadline expires; in that case the method
+// returns `false` and an error matching `ErrCanceled` or `ErrTimeout`
+// respectively. If no verification slot is available (see
+// [TVerifyLimiter]) the method returns `false` and `ErrBusy`.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aUser`: The username to lookup.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `bool`: `true` if a match was found, or `false` otherwise.
+//   - `error`: A reason why the verification couldn't be completed.
+func (ul *TPassList) MatchesContext(aCtx context.Context, aUser, aPassword string) (bool, error) {
+	return ul.tVerifier.matches(aCtx, aUser, aPassword, ul.Find)
+} // MatchesContext()
+
 // `read()` parses the a file using `aScanner`, returning
 // the number of bytes read and a possible error.
 //
@@ -422,6 +515,43 @@ func (ul *TPassList) Remove(aUser string) *TPassList {
 	return ul
 } // Remove()
 
+// `SetCache()` sets the cache of verified credentials to use by
+// this list.
+//
+// Credentials found in the cache are accepted without running a
+// full password hash comparison. Cache entries become invalid if
+// the user's password hash changes or the list is (re-)loaded.
+//
+// If `aCache` is `nil` no caching is done.
+//
+// Parameters:
+//   - `aCache`: The credentials cache to use.
+//
+// Returns:
+//   - `*TPassList`: The updated list.
+func (ul *TPassList) SetCache(aCache *TAuthCache) *TPassList {
+	ul.cache = aCache
+
+	return ul
+} // SetCache()
+
+// `SetLimiter()` sets the limiter of concurrent password verifications
+// to use by this list.
+//
+// If `aLimiter` is `nil` the package-wide limiter (see [SetVerifyLimit])
+// is used.
+//
+// Parameters:
+//   - `aLimiter`: The verification limiter to use.
+//
+// Returns:
+//   - `*TPassList`: The updated list.
+func (ul *TPassList) SetLimiter(aLimiter *TVerifyLimiter) *TPassList {
+	ul.limiter = aLimiter
+
+	return ul
+} // SetLimiter()
+
 // `Store()` writes the list to a file, truncating the file
 // if it already exists.
 //
@@ -520,50 +650,36 @@ func (as TAuthSkipper) NeedAuthentication(aRequest *http.Request) bool {
 // `Wrap ()`returns a handler function that includes authentication,
 // wrapping the given `aNext` and calling it internally.
 //
+// This function is a shortcut for
+//
+//	NewMiddleware(WithRealm(aRealm), WithPasswdFile(aPasswdFile),
+//		WithDecider(aAuthDecider), WithFailOpen(true)).Wrap(aNext)
+//
+// see [NewMiddleware] for more configuration options.
+//
+// NOTE: If `aPasswdFile` is missing or broken, or `aAuthDecider` is
+// `nil`, this function logs "AUTHENTICATION DISABLED!" and returns
+// `aNext` unprotected. Use [NewMiddleware] or [LoadMiddleware] to
+// fail closed instead.
+//
+// If the password verification is rejected by the verification
+// limiter (see [SetVerifyLimit]) or doesn't finish before the request's
+// deadline the remote host gets a "503 Service Unavailable" response
+// with a `Retry-After` header. If the request is cancelled (e.g. the
+// remote host disconnected) nothing is sent at all.
+//
 // Parameters:
 //   - `aNext`: The handler to be called after successful authentication.
 //   - `aRealm`: The symbolic name of the domain/host to protect.
 //   - `aPasswdFile`: The name of the password file to use.
-//   - `aAuthDecider`:
+//   - `aAuthDecider`: The decider whether a request needs authentication.
 func Wrap(aNext http.Handler, aRealm, aPasswdFile string, aAuthDecider IAuthDecider) http.Handler {
-	if aPasswdFile = strings.TrimSpace(aPasswdFile); "" == aPasswdFile {
-		log.Print("passlist.Wrap(): missing password file\nAUTHENTICATION DISABLED!\n")
-		// Without a password file we can't do authentication.
-		return aNext
-	}
-
-	if nil == aAuthDecider {
-		log.Print("passlist.Wrap(): missing AuthDecider\nAUTHENTICATION DISABLED!\n")
-		// Without a decider we skip the authentication procedure.
-		return aNext
-	}
-
-	ul, err := LoadPasswords(aPasswdFile)
-	if nil != err {
-		log.Printf("passlist.Wrap(): %v\nAUTHENTICATION DISABLED!\n", err)
-		// We can't do anything w/o password file, so we skip
-		// the whole authentication procedure.
-		return aNext
-	}
-
-	if aRealm = strings.TrimSpace(aRealm); "" == aRealm {
-		aRealm = `<unknown>`
-	}
-
-	newHandler := func(aWriter http.ResponseWriter, aRequest *http.Request) {
-		if aAuthDecider.NeedAuthentication(aRequest) {
-			// `ul` and `aRealm` are defined in the embedding closure (above).
-			if err := ul.IsAuthenticated(aRequest); nil != err {
-				Deny(aRealm, aWriter)
-				return
-			}
-		}
-
-		// Call the previous/original handler:
-		aNext.ServeHTTP(aWriter, aRequest)
-	}
-
-	return http.HandlerFunc(newHandler)
+	return NewMiddleware(
+		WithRealm(aRealm),
+		WithPasswdFile(aPasswdFile),
+		WithDecider(aAuthDecider),
+		WithFailOpen(true),
+	).Wrap(aNext)
 } // Wrap()
 
 /* _EoF_ */
@@ -7,11 +7,14 @@ Copyright © 2019, 2025 M.Watermann, 10247 Berlin, Germany
 package passlist
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -41,8 +44,8 @@ func Test_LoadPasswords(t *testing.T) {
 	}()
 
 	wl1 := &TPassList{
-		ul.filename,
-		tUserMap{
+		filename: ul.filename,
+		usermap: tUserMap{
 			u1: p1,
 			u2: p2,
 			u3: p3,
@@ -155,16 +158,16 @@ func Test_TUserList_add0(t *testing.T) {
 	ul := prepDB()
 	u1, p1 := "username1", "password1"
 	wl1 := &TPassList{
-		ul.filename,
-		tUserMap{
+		filename: ul.filename,
+		usermap: tUserMap{
 			u1: p1,
 		},
 	}
 
 	u2, p2 := "username2", "password2"
 	wl2 := &TPassList{
-		ul.filename,
-		tUserMap{
+		filename: ul.filename,
+		usermap: tUserMap{
 			u1: p1,
 			u2: p2,
 		},
@@ -203,8 +206,8 @@ func Test_TUserList_Clear(t *testing.T) {
 	ul := prepDB().add0(u1, xxHash(p1)).add0(u2, xxHash(p2))
 
 	wl1 := &TPassList{
-		ul.filename,
-		make(tUserMap, 8),
+		filename: ul.filename,
+		usermap:  make(tUserMap, 8),
 	}
 	tests := []struct {
 		name string
@@ -325,12 +328,41 @@ func Test_TPassList_IsAuthenticated(t *testing.T) {
 				// Check that user info was stored in request URL
 				if nil == tt.req.URL.User {
 					t.Errorf("TPassList.IsAuthenticated() did not store user info in request URL")
+				} else if _, set := tt.req.URL.User.Password(); set {
+					t.Errorf("TPassList.IsAuthenticated() stored a password in request URL")
 				}
 			}
 		})
 	}
 } // Test_TPassList_IsAuthenticated()
 
+func Test_TPassList_AuthenticateContext(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	req1, _ := http.NewRequest("GET", "http://example.com", nil)
+	req1.SetBasicAuth(u1, "wrongpassword")
+
+	req2, _ := http.NewRequest("GET", "http://example.com", nil)
+	req2.SetBasicAuth("nonexistentuser", p1)
+
+	err1 := ul.AuthenticateContext(context.Background(), req1)
+	err2 := ul.AuthenticateContext(context.Background(), req2)
+	if !errors.Is(err1, ErrInvalidCredentials) || !errors.Is(err2, ErrInvalidCredentials) {
+		t.Errorf("TPassList.AuthenticateContext() errors = '%v' / '%v', want '%v'",
+			err1, err2, ErrInvalidCredentials)
+	}
+	if err1.Error() != err2.Error() {
+		t.Errorf("TPassList.AuthenticateContext() errors differ: '%v' / '%v'",
+			err1, err2)
+	}
+
+	cost, err := bcrypt.Cost([]byte(dummyHash()))
+	if (nil != err) || (pwCost != cost) {
+		t.Errorf("dummyHash() cost = %d, want %d (%v)", cost, pwCost, err)
+	}
+} // Test_TPassList_AuthenticateContext()
+
 func Test_TUserList_Len(t *testing.T) {
 	u1, p1 := "username1", "password1"
 	u2, p2 := "username2", "password2"
@@ -463,20 +495,58 @@ func Test_TPassList_Matches(t *testing.T) {
 	}
 } // Test_TPassList_Matches()
 
+func Test_TPassList_MatchesContext(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel2 := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel2()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		user    string
+		pass    string
+		want    bool
+		wantErr error
+	}{
+		{" 1", context.Background(), u1, p1, true, nil},
+		{" 2", context.Background(), u1, "wrongpass", false, nil},
+		{" 3", context.Background(), "nobody", p1, false, nil},
+		{" 4", cancelled, u1, p1, false, ErrCanceled},
+		{" 5", expired, u1, p1, false, ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ul.MatchesContext(tt.ctx, tt.user, tt.pass)
+			if got != tt.want {
+				t.Errorf("TPassList.MatchesContext() = '%v', want '%v'",
+					got, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TPassList.MatchesContext() error = '%v', want '%v'",
+					err, tt.wantErr)
+			}
+		})
+	}
+} // Test_TPassList_MatchesContext()
+
 func Test_TUserList_Remove(t *testing.T) {
 	u1, p1 := "username1", "password1"
 	u2, p2 := "username2", "password2"
 	ul := prepDB().add0(u1, p1).add0(u2, p2)
 
 	wl1 := &TPassList{
-		ul.filename,
-		tUserMap{
+		filename: ul.filename,
+		usermap: tUserMap{
 			u1: p1,
 			u2: p2},
 	}
 	wl2 := &TPassList{
-		ul.filename,
-		tUserMap{
+		filename: ul.filename,
+		usermap: tUserMap{
 			u2: p2},
 	}
 	wl3 := prepDB()
@@ -0,0 +1,164 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the authenticated principal passed along
+ * with a request's context.
+ */
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `AuthAPIKey` is the authentication method of principals
+	// authenticated by an API key.
+	AuthAPIKey = "APIKey"
+
+	// `AuthBasic` is the authentication method of principals
+	// authenticated by HTTP Basic authentication.
+	AuthBasic = "Basic"
+
+	// `AuthCert` is the authentication method of principals
+	// authenticated by a TLS client certificate.
+	AuthCert = "Certificate"
+
+	// `AuthDigest` is the authentication method of principals
+	// authenticated by HTTP Digest authentication.
+	AuthDigest = "Digest"
+
+	// `AuthHeader` is the authentication method of principals
+	// reported by a trusted proxy's identity header.
+	AuthHeader = "Header"
+
+	// `AuthJWT` is the authentication method of principals
+	// authenticated by a JSON Web Token.
+	AuthJWT = "JWT"
+
+	// `AuthSession` is the authentication method of principals
+	// authenticated by a session cookie.
+	AuthSession = "Session"
+)
+
+type (
+	// `TPrincipal` describes an authenticated remote user.
+	//
+	// The middleware attaches the principal to the context of each
+	// successfully authenticated request; use [PrincipalFromContext]
+	// or [UserFromRequest] to retrieve it.
+	TPrincipal struct {
+		Name       string            // the user's name
+		Roles      []string          // the user's roles/groups
+		Scopes     []string          // the scopes granted to the credentials
+		Attributes map[string]string // additional scheme specific data
+		AuthMethod string            // the authentication scheme used
+		AuthTime   time.Time         // the time of authentication
+	}
+
+	// `TRoleFunc` returns the roles (or groups) of `aUser`.
+	TRoleFunc func(aUser string) []string
+
+	// `tPrincipalKey` is the context key of the principal.
+	tPrincipalKey struct{}
+)
+
+// `ContextWithPrincipal()` returns a copy of `aCtx` carrying
+// `aPrincipal`.
+//
+// Parameters:
+//   - `aCtx`: The parent context.
+//   - `aPrincipal`: The authenticated principal.
+//
+// Returns:
+//   - `context.Context`: The new context.
+func ContextWithPrincipal(aCtx context.Context, aPrincipal *TPrincipal) context.Context {
+	return context.WithValue(aCtx, tPrincipalKey{}, aPrincipal)
+} // ContextWithPrincipal()
+
+// `PrincipalFromContext()` returns the principal carried by `aCtx`.
+//
+// Parameters:
+//   - `aCtx`: The context to check.
+//
+// Returns:
+//   - `*TPrincipal`: The principal, or `nil` if there's none.
+func PrincipalFromContext(aCtx context.Context) *TPrincipal {
+	if nil == aCtx {
+		return nil
+	}
+	p, _ := aCtx.Value(tPrincipalKey{}).(*TPrincipal)
+
+	return p
+} // PrincipalFromContext()
+
+// `UserFromRequest()` returns the name of the authenticated user
+// of `aRequest`.
+//
+// The name is taken from the principal in the request's context or,
+// if there's none, from the request's `URL.User` field (as set by
+// [TPassList.IsAuthenticated]).
+//
+// Parameters:
+//   - `aRequest`: The HTTP request to check.
+//
+// Returns:
+//   - `string`: The user's name, or an empty string if unknown.
+func UserFromRequest(aRequest *http.Request) string {
+	if nil == aRequest {
+		return ""
+	}
+	if p := PrincipalFromContext(aRequest.Context()); nil != p {
+		return p.Name
+	}
+	if nil != aRequest.URL {
+		return aRequest.URL.User.Username()
+	}
+
+	return ""
+} // UserFromRequest()
+
+// --------------------------------------------------------------------------
+// `TPrincipal` methods:
+
+// `HasRole()` returns whether the principal has `aRole`.
+//
+// Parameters:
+//   - `aRole`: The role to check.
+//
+// Returns:
+//   - `bool`: `true` if the principal has the role, or `false` otherwise.
+func (p *TPrincipal) HasRole(aRole string) bool {
+	if nil == p {
+		return false
+	}
+
+	return slices.Contains(p.Roles, aRole)
+} // HasRole()
+
+// `HasScope()` returns whether the principal's credentials grant
+// `aScope`.
+//
+// Parameters:
+//   - `aScope`: The scope to check.
+//
+// Returns:
+//   - `bool`: `true` if the principal has the scope, or `false` otherwise.
+func (p *TPrincipal) HasScope(aScope string) bool {
+	if nil == p {
+		return false
+	}
+
+	return slices.Contains(p.Scopes, aScope)
+} // HasScope()
+
+/* _EoF_ */
@@ -0,0 +1,126 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_PrincipalFromContext(t *testing.T) {
+	p1 := &TPrincipal{Name: "username1"}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *TPrincipal
+	}{
+		{" 1", ContextWithPrincipal(context.Background(), p1), p1},
+		{" 2", context.Background(), nil},
+		{" 3", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrincipalFromContext(tt.ctx); got != tt.want {
+				t.Errorf("PrincipalFromContext() = %v, want %v",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_PrincipalFromContext()
+
+func Test_UserFromRequest(t *testing.T) {
+	req1 := httptest.NewRequest("GET", "http://example.com/", nil)
+	req1 = req1.WithContext(ContextWithPrincipal(req1.Context(),
+		&TPrincipal{Name: "username1"}))
+
+	req2 := httptest.NewRequest("GET", "http://example.com/", nil)
+	req2.URL.User = url.UserPassword("username2", "hash")
+
+	req3 := httptest.NewRequest("GET", "http://example.com/", nil)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{" 1", req1, "username1"},
+		{" 2", req2, "username2"},
+		{" 3", req3, ""},
+		{" 4", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UserFromRequest(tt.req); got != tt.want {
+				t.Errorf("UserFromRequest() = %q, want %q",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_UserFromRequest()
+
+func Test_TPrincipal_HasRole(t *testing.T) {
+	p1 := &TPrincipal{Name: "username1", Roles: []string{"admin", "staff"}}
+
+	tests := []struct {
+		name string
+		p    *TPrincipal
+		role string
+		want bool
+	}{
+		{" 1", p1, "admin", true},
+		{" 2", p1, "staff", true},
+		{" 3", p1, "guest", false},
+		{" 4", nil, "admin", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.HasRole(tt.role); got != tt.want {
+				t.Errorf("TPrincipal.HasRole() = %v, want %v",
+					got, tt.want)
+			}
+		})
+	}
+} // Test_TPrincipal_HasRole()
+
+func Test_TMiddleware_principal(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+
+	for _, urlUser := range []bool{true, false} {
+		var got *TPrincipal
+		var gotURL *url.Userinfo
+		next := http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+			got = PrincipalFromContext(aRequest.Context())
+			gotURL = aRequest.URL.User
+		})
+		handler := NewMiddleware(
+			WithList(ul),
+			WithDecider(TAuthNeeder{}),
+			WithRoles(func(aUser string) []string {
+				return []string{"role-of-" + aUser}
+			}),
+			WithURLUser(urlUser),
+		).Wrap(next)
+		_ = serve(handler, u1, p1)
+
+		if (nil == got) || (u1 != got.Name) || (AuthBasic != got.AuthMethod) {
+			t.Fatalf("PrincipalFromContext() = %v", got)
+		}
+		if !got.HasRole("role-of-" + u1) {
+			t.Errorf("TPrincipal.Roles = %v", got.Roles)
+		}
+		if (nil != gotURL) != urlUser {
+			t.Errorf("URL.User = %v, want set: %v", gotURL, urlUser)
+		}
+	}
+} // Test_TMiddleware_principal()
+
+/* _EoF_ */
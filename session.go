@@ -0,0 +1,493 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides signed session cookies issued after a
+ * successful password authentication.
+ */
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+const (
+	// `DefaultSessionCookie` is the default name of the session cookie.
+	DefaultSessionCookie = "passlist_session"
+
+	// Max. number of signing keys kept by `RotateKey()`.
+	sessionMaxKeys = 3
+
+	// Version tag of the cookie's payload format.
+	sessionVersion = "s1"
+)
+
+var (
+	// `ErrSessionInvalid` is returned if a session cookie is invalid,
+	// expired, or revoked.
+	ErrSessionInvalid = errors.New("invalid session")
+)
+
+type (
+	// `tSession` is the decoded payload of a session cookie.
+	tSession struct {
+		id     string    // random session ID
+		user   string    // the authenticated user
+		issued time.Time // time of the password authentication
+		last   time.Time // time of the latest activity
+		pwFP   string    // fingerprint of the user's password hash
+	}
+
+	// `TSessions` issues and validates signed session cookies, thus
+	// allowing authenticated users to skip the (slow) password
+	// verification with their subsequent requests.
+	//
+	// The sessions aren't stored on the server: each cookie carries
+	// its user, creation and activity times, and a fingerprint of
+	// the user's password hash, all signed with an HMAC key. Only
+	// revocations are kept in memory.
+	//
+	// A session becomes invalid if
+	//   - there was no request for the idle timeout,
+	//   - the absolute timeout since the login has passed,
+	//   - the session or all sessions of its user were revoked,
+	//   - the user's password was changed, or the user was removed,
+	//   - the key it was signed with was rotated out.
+	TSessions struct {
+		mtx      sync.RWMutex         // protect concurrent access
+		keys     [][]byte             // signing keys, current one first
+		revoked  map[string]time.Time // revoked session IDs (and their expiry)
+		users    map[string]time.Time // users' revocation times
+		name     string               // name of the cookie
+		path     string               // path of the cookie
+		idle     time.Duration        // max. time between two requests
+		absolute time.Duration        // max. lifetime of a session
+		insecure bool                 // don't set the `Secure` attribute
+	}
+)
+
+// `NewSessions()` returns a new session manager.
+//
+// If `aIdle` isn't positive it defaults to 30 minutes; if `aAbsolute`
+// is less than `aIdle` it defaults to eight hours (or `aIdle`,
+// whichever is greater). A random signing key is created; use
+// [TSessions.SetKeys] to share keys between several instances.
+//
+// Parameters:
+//   - `aIdle`: The max. time between two requests of a session.
+//   - `aAbsolute`: The max. lifetime of a session.
+//
+// Returns:
+//   - `*TSessions`: The new session manager.
+func NewSessions(aIdle, aAbsolute time.Duration) *TSessions {
+	if 0 >= aIdle {
+		aIdle = 30 * time.Minute
+	}
+	if aAbsolute < aIdle {
+		aAbsolute = max(8*time.Hour, aIdle)
+	}
+
+	return &TSessions{
+		keys:     [][]byte{newSessionKey()},
+		revoked:  make(map[string]time.Time, 16),
+		users:    make(map[string]time.Time, 16),
+		name:     DefaultSessionCookie,
+		path:     "/",
+		idle:     aIdle,
+		absolute: aAbsolute,
+	}
+} // NewSessions()
+
+// --------------------------------------------------------------------------
+// `TSessions` methods:
+
+// `check()` returns the valid session carried by `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request received by a server.
+//   - `aFind`: The function returning a user's password hash.
+//
+// Returns:
+//   - `*tSession`: The session found.
+//   - `error`: `ErrSessionInvalid` if there's no valid session.
+func (s *TSessions) check(aRequest *http.Request, aFind tFindFunc) (*tSession, error) {
+	cookie, err := aRequest.Cookie(s.name)
+	if nil != err {
+		return nil, ErrSessionInvalid
+	}
+	sess, ok := s.decode(cookie.Value)
+	if !ok {
+		return nil, ErrSessionInvalid
+	}
+
+	now := time.Now()
+	if (now.Sub(sess.last) > s.idle) || (now.Sub(sess.issued) > s.absolute) {
+		return nil, ErrSessionInvalid
+	}
+
+	s.mtx.RLock()
+	_, revoked := s.revoked[sess.id]
+	since, userRevoked := s.users[sess.user]
+	s.mtx.RUnlock()
+	if revoked || (userRevoked && !sess.issued.After(since)) {
+		return nil, ErrSessionInvalid
+	}
+
+	pwHash, err := aFind(sess.user)
+	if (nil != err) || !hmac.Equal([]byte(sess.pwFP), []byte(sessionFingerprint(pwHash))) {
+		// user removed or password changed
+		return nil, ErrSessionInvalid
+	}
+
+	return sess, nil
+} // check()
+
+// `Clear()` sends a cookie removing the session cookie from the
+// remote host.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+func (s *TSessions) Clear(aWriter http.ResponseWriter) {
+	http.SetCookie(aWriter, &http.Cookie{
+		Name:     s.name,
+		Value:    "",
+		Path:     s.path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   !s.insecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+} // Clear()
+
+// `decode()` returns the session encoded in `aValue`.
+//
+// Parameters:
+//   - `aValue`: The cookie's value.
+//
+// Returns:
+//   - `*tSession`: The decoded session.
+//   - `bool`: `true` if the value was validly signed.
+func (s *TSessions) decode(aValue string) (*tSession, bool) {
+	data, sig, ok := strings.Cut(aValue, ".")
+	if !ok {
+		return nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if nil != err {
+		return nil, false
+	}
+
+	s.mtx.RLock()
+	valid := false
+	for _, key := range s.keys {
+		if hmac.Equal(mac, sessionMAC(key, data)) {
+			valid = true
+			break
+		}
+	}
+	s.mtx.RUnlock()
+	if !valid {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+	if nil != err {
+		return nil, false
+	}
+	fields := strings.Split(string(payload), "\n")
+	if (6 != len(fields)) || (sessionVersion != fields[0]) {
+		return nil, false
+	}
+	issued, err1 := strconv.ParseInt(fields[3], 10, 64)
+	last, err2 := strconv.ParseInt(fields[4], 10, 64)
+	if (nil != err1) || (nil != err2) {
+		return nil, false
+	}
+
+	return &tSession{
+		id:     fields[1],
+		user:   fields[2],
+		issued: time.Unix(issued, 0),
+		last:   time.Unix(last, 0),
+		pwFP:   fields[5],
+	}, true
+} // decode()
+
+// `encode()` returns the signed cookie value of `aSession`.
+//
+// Parameters:
+//   - `aSession`: The session to encode.
+//
+// Returns:
+//   - `string`: The cookie's value.
+func (s *TSessions) encode(aSession *tSession) string {
+	payload := strings.Join([]string{
+		sessionVersion,
+		aSession.id,
+		aSession.user,
+		strconv.FormatInt(aSession.issued.Unix(), 10),
+		strconv.FormatInt(aSession.last.Unix(), 10),
+		aSession.pwFP,
+	}, "\n")
+	data := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	s.mtx.RLock()
+	mac := sessionMAC(s.keys[0], data)
+	s.mtx.RUnlock()
+
+	return data + "." + base64.RawURLEncoding.EncodeToString(mac)
+} // encode()
+
+// `issue()` sends a new session cookie for `aUser` to the remote host.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aUser`: The authenticated user.
+//   - `aPwHash`: The user's current password hash.
+func (s *TSessions) issue(aWriter http.ResponseWriter, aUser, aPwHash string) {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	now := time.Now()
+
+	s.send(aWriter, &tSession{
+		id:     base64.RawURLEncoding.EncodeToString(id),
+		user:   aUser,
+		issued: now,
+		last:   now,
+		pwFP:   sessionFingerprint(aPwHash),
+	})
+} // issue()
+
+// `refresh()` updates the activity time of `aSession` sending a new
+// cookie if the previous update is long enough ago.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aSession`: The session to refresh.
+func (s *TSessions) refresh(aWriter http.ResponseWriter, aSession *tSession) {
+	// To avoid sending a new cookie with each and every response
+	// the activity time is updated only after a quarter of the
+	// idle timeout.
+	now := time.Now()
+	if now.Sub(aSession.last) < s.idle/4 {
+		return
+	}
+	aSession.last = now
+	s.send(aWriter, aSession)
+} // refresh()
+
+// `Revoke()` invalidates the session cookie sent with `aRequest`.
+//
+// Parameters:
+//   - `aRequest`: The HTTP request carrying the session cookie.
+func (s *TSessions) Revoke(aRequest *http.Request) {
+	cookie, err := aRequest.Cookie(s.name)
+	if nil != err {
+		return
+	}
+	if sess, ok := s.decode(cookie.Value); ok {
+		s.RevokeID(sess.id)
+	}
+} // Revoke()
+
+// `RevokeID()` invalidates the session identified by `aID`.
+//
+// Parameters:
+//   - `aID`: The ID of the session to revoke.
+func (s *TSessions) RevokeID(aID string) {
+	now := time.Now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sweep(now)
+	s.revoked[aID] = now.Add(s.absolute)
+} // RevokeID()
+
+// `RevokeUser()` invalidates all sessions of `aUser` created so far.
+//
+// Parameters:
+//   - `aUser`: The user whose sessions to revoke.
+func (s *TSessions) RevokeUser(aUser string) {
+	now := time.Now()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.sweep(now)
+	s.users[aUser] = now
+} // RevokeUser()
+
+// `RotateKey()` creates a new signing key.
+//
+// Cookies signed with one of the two previous keys remain valid,
+// older ones become invalid.
+func (s *TSessions) RotateKey() {
+	key := newSessionKey()
+
+	s.mtx.Lock()
+	s.keys = append([][]byte{key}, s.keys[:min(len(s.keys), sessionMaxKeys-1)]...)
+	s.mtx.Unlock()
+} // RotateKey()
+
+// `send()` sends the cookie of `aSession` to the remote host.
+//
+// Parameters:
+//   - `aWriter`: Used by an HTTP handler to construct an HTTP response.
+//   - `aSession`: The session to send.
+func (s *TSessions) send(aWriter http.ResponseWriter, aSession *tSession) {
+	http.SetCookie(aWriter, &http.Cookie{
+		Name:     s.name,
+		Value:    s.encode(aSession),
+		Path:     s.path,
+		MaxAge:   int(time.Until(aSession.issued.Add(s.absolute)).Seconds()),
+		HttpOnly: true,
+		Secure:   !s.insecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+} // send()
+
+// `SetCookie()` sets the name and path of the session cookie.
+//
+// Parameters:
+//   - `aName`: The cookie's name (empty for the default name).
+//   - `aPath`: The cookie's path (empty for "/").
+//
+// Returns:
+//   - `*TSessions`: The updated instance.
+func (s *TSessions) SetCookie(aName, aPath string) *TSessions {
+	if aName = strings.TrimSpace(aName); "" == aName {
+		aName = DefaultSessionCookie
+	}
+	if aPath = strings.TrimSpace(aPath); "" == aPath {
+		aPath = "/"
+	}
+	s.name, s.path = aName, aPath
+
+	return s
+} // SetCookie()
+
+// `SetInsecure()` decides whether the cookie may be sent over
+// unencrypted connections.
+//
+// By default the cookie's `Secure` attribute is set; only disable it
+// for local testing.
+//
+// Parameters:
+//   - `aInsecure`: Whether to omit the `Secure` attribute.
+//
+// Returns:
+//   - `*TSessions`: The updated instance.
+func (s *TSessions) SetInsecure(aInsecure bool) *TSessions {
+	s.insecure = aInsecure
+
+	return s
+} // SetInsecure()
+
+// `SetKeys()` sets the signing keys, e.g. to share the sessions
+// between several instances of a server.
+//
+// The first key is used to sign new cookies while all keys are
+// accepted when validating cookies. Keys shorter than 32 bytes
+// are ignored; if there's no valid key at all the current keys
+// remain in use.
+//
+// Parameters:
+//   - `aKeys`: The keys to use.
+//
+// Returns:
+//   - `*TSessions`: The updated instance.
+func (s *TSessions) SetKeys(aKeys ...[]byte) *TSessions {
+	keys := make([][]byte, 0, len(aKeys))
+	for _, key := range aKeys {
+		if sha256.Size <= len(key) {
+			keys = append(keys, bytes.Clone(key))
+		}
+	}
+	if 0 < len(keys) {
+		s.mtx.Lock()
+		s.keys = keys
+		s.mtx.Unlock()
+	}
+
+	return s
+} // SetKeys()
+
+// `sweep()` removes all revocations of expired sessions.
+//
+// NOTE: The caller must hold the write lock.
+//
+// Parameters:
+//   - `aNow`: The current time.
+func (s *TSessions) sweep(aNow time.Time) {
+	for id, expires := range s.revoked {
+		if aNow.After(expires) {
+			delete(s.revoked, id)
+		}
+	}
+	for user, since := range s.users {
+		if aNow.Sub(since) > s.absolute {
+			delete(s.users, user)
+		}
+	}
+} // sweep()
+
+// --------------------------------------------------------------------------
+// Helper functions:
+
+// `newSessionKey()` returns a new random signing key.
+//
+// Returns:
+//   - `[]byte`: The new key.
+func newSessionKey() []byte {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+
+	return key
+} // newSessionKey()
+
+// `sessionFingerprint()` returns the fingerprint of `aPwHash`.
+//
+// Parameters:
+//   - `aPwHash`: The user's password hash.
+//
+// Returns:
+//   - `string`: The hash's fingerprint.
+func sessionFingerprint(aPwHash string) string {
+	sum := sha256.Sum256([]byte(aPwHash))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+} // sessionFingerprint()
+
+// `sessionMAC()` returns the signature of `aData` using `aKey`.
+//
+// Parameters:
+//   - `aKey`: The signing key.
+//   - `aData`: The data to sign.
+//
+// Returns:
+//   - `[]byte`: The signature.
+func sessionMAC(aKey []byte, aData string) []byte {
+	mac := hmac.New(sha256.New, aKey)
+	mac.Write([]byte(aData))
+
+	return mac.Sum(nil)
+} // sessionMAC()
+
+/* _EoF_ */
@@ -0,0 +1,201 @@
+/*
+Copyright © 2026 M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// sessionRequest is an internal test helper returning a request
+// carrying a session cookie for `aSession`.
+func sessionRequest(aSessions *TSessions, aSession *tSession) *http.Request {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.AddCookie(&http.Cookie{Name: aSessions.name, Value: aSessions.encode(aSession)})
+
+	return req
+} // sessionRequest()
+
+func Test_TSessions_check(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	pwHash, _ := ul.Find(u1)
+	s := NewSessions(time.Minute, time.Hour)
+	now := time.Now()
+
+	valid := func() *tSession {
+		return &tSession{id: "id1", user: u1, issued: now, last: now, pwFP: sessionFingerprint(pwHash)}
+	}
+	idle, absolute, changed, removed := valid(), valid(), valid(), valid()
+	idle.last = now.Add(-2 * time.Minute)
+	absolute.issued = now.Add(-2 * time.Hour)
+	changed.pwFP = sessionFingerprint("old hash")
+	removed.user = "username2"
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want bool
+	}{
+		{" 1", sessionRequest(s, valid()), true},
+		{" 2", sessionRequest(s, idle), false},
+		{" 3", sessionRequest(s, absolute), false},
+		{" 4", sessionRequest(s, changed), false},
+		{" 5", sessionRequest(s, removed), false},
+		{" 6", httptest.NewRequest("GET", "http://example.com/", nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.check(tt.req, ul.Find)
+			if got := (nil == err); got != tt.want {
+				t.Errorf("TSessions.check() error = %v, want valid = %v", err, tt.want)
+			}
+		})
+	}
+
+	// tampered cookie:
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	other := NewSessions(time.Minute, time.Hour)
+	req.AddCookie(&http.Cookie{Name: s.name, Value: other.encode(valid())})
+	if _, err := s.check(req, ul.Find); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("TSessions.check() error = %v, want %v", err, ErrSessionInvalid)
+	}
+} // Test_TSessions_check()
+
+func Test_TSessions_revoke(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	pwHash, _ := ul.Find(u1)
+	s := NewSessions(time.Minute, time.Hour)
+	now := time.Now().Add(-time.Second)
+	sess1 := &tSession{id: "id1", user: u1, issued: now, last: now, pwFP: sessionFingerprint(pwHash)}
+	sess2 := &tSession{id: "id2", user: u1, issued: now, last: now, pwFP: sessionFingerprint(pwHash)}
+
+	s.Revoke(sessionRequest(s, sess1))
+	if _, err := s.check(sessionRequest(s, sess1), ul.Find); nil == err {
+		t.Error("TSessions.Revoke() didn't invalidate the session")
+	}
+	if _, err := s.check(sessionRequest(s, sess2), ul.Find); nil != err {
+		t.Errorf("TSessions.Revoke() invalidated another session: %v", err)
+	}
+
+	s.RevokeUser(u1)
+	if _, err := s.check(sessionRequest(s, sess2), ul.Find); nil == err {
+		t.Error("TSessions.RevokeUser() didn't invalidate the session")
+	}
+	sess2.issued = time.Now().Add(time.Second)
+	if _, err := s.check(sessionRequest(s, sess2), ul.Find); nil != err {
+		t.Errorf("TSessions.RevokeUser() invalidated a later session: %v", err)
+	}
+} // Test_TSessions_revoke()
+
+func Test_TSessions_RotateKey(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	pwHash, _ := ul.Find(u1)
+	s := NewSessions(time.Minute, time.Hour)
+	now := time.Now()
+	req := sessionRequest(s, &tSession{id: "id1", user: u1, issued: now, last: now, pwFP: sessionFingerprint(pwHash)})
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{" 1", true},
+		{" 2", true},
+		{" 3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.RotateKey()
+			_, err := s.check(req, ul.Find)
+			if got := (nil == err); got != tt.want {
+				t.Errorf("TSessions.check() error = %v, want valid = %v", err, tt.want)
+			}
+		})
+	}
+} // Test_TSessions_RotateKey()
+
+func Test_TMiddleware_sessions(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	var (
+		method  string
+		urlUser *url.Userinfo
+	)
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithSessions(NewSessions(time.Minute, time.Hour)),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		method = PrincipalFromContext(aRequest.Context()).AuthMethod
+		urlUser = aRequest.URL.User
+	}))
+
+	rec := serve(handler, u1, p1)
+	cookies := rec.Result().Cookies()
+	if (http.StatusOK != rec.Code) || (1 != len(cookies)) {
+		t.Fatalf("TMiddleware.Wrap() status = %d, cookies = %v", rec.Code, cookies)
+	}
+	if !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Errorf("TMiddleware.Wrap() cookie = %v, want HttpOnly and Secure", cookies[0])
+	}
+
+	request := func() int {
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+	if got := request(); (http.StatusOK != got) || (AuthSession != method) {
+		t.Errorf("TMiddleware.Wrap() status = %d, method = %q", got, method)
+	}
+	if _, set := urlUser.Password(); (nil == urlUser) || set || (u1 != urlUser.Username()) {
+		t.Errorf("TMiddleware.Wrap() URL.User = %v, want %q only", urlUser, u1)
+	}
+
+	// changing the password invalidates the session:
+	_ = ul.Add(u1, "password2")
+	if got := request(); http.StatusUnauthorized != got {
+		t.Errorf("TMiddleware.Wrap() status = %d, want %d", got, http.StatusUnauthorized)
+	}
+} // Test_TMiddleware_sessions()
+
+func Test_TMiddleware_sessionsProxy(t *testing.T) {
+	u1, p1 := "username1", "password1"
+	ul := prepDB().add0(u1, xxHash(p1))
+	handler := NewMiddleware(
+		WithList(ul),
+		WithDecider(TAuthNeeder{}),
+		WithProxy(true),
+		WithSessions(NewSessions(time.Minute, time.Hour)),
+		WithLogger(quietLogger),
+	).Wrap(http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.SetBasicAuth(u1, p1)
+	req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+	req.Header.Del("Authorization")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if http.StatusOK != rec.Code {
+		t.Fatalf("TMiddleware.Wrap() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Result().Cookies(); 0 != len(got) {
+		t.Errorf("TMiddleware.Wrap() set cookies %v in proxy mode", got)
+	}
+} // Test_TMiddleware_sessionsProxy()
+
+/* _EoF_ */
@@ -0,0 +1,227 @@
+/*
+Copyright © 2026  M.Watermann, 10247 Berlin, Germany
+
+	    All rights reserved
+	EMail : <support@mwat.de>
+*/
+package passlist
+
+/*
+ * This file provides the password verification shared by
+ * the different user list implementations.
+ */
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	se "github.com/mwat56/sourceerror"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//lint:file-ignore ST1017 - I prefer Yoda conditions
+
+var (
+	// `ErrInvalidCredentials` is returned if a request's credentials
+	// are wrong – no matter whether the user or the password is wrong.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// Hash of a random password used to verify unknown users.
+	pwDummyHash     string
+	pwDummyHashOnce sync.Once
+)
+
+type (
+	// `tFindFunc` returns the stored password hash of a user.
+	tFindFunc func(aUser string) (string, error)
+
+	// `tVerifier` holds the optional helpers used when verifying
+	// passwords against their stored hashes.
+	tVerifier struct {
+		limiter *TVerifyLimiter // optional verification limiter
+		cache   *TAuthCache     // optional cache of verified credentials
+	}
+)
+
+// `authenticate()` checks the Basic authentication data in the
+// `aHeader` header of `aRequest` using `aFind` to lookup the stored
+// password hash.
+//
+// On success the username (but not the password or its hash) is
+// stored in the `aRequest.URL.User` structure to allow for other
+// handlers checking its existence and act accordingly.
+//
+// Both unknown users and wrong passwords result in the same
+// `ErrInvalidCredentials` after (roughly) the same time.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aRequest` The HTTP request received by a server.
+//   - `aHeader`: Either "Authorization" or "Proxy-Authorization".
+//   - `aFind`: The function returning a user's password hash.
+//
+// Returns:
+//   - `error`: A possible error during processing the request.
+func (v tVerifier) authenticate(aCtx context.Context, aRequest *http.Request, aHeader string, aFind tFindFunc) error {
+	user, pass, ok := parseBasic(aRequest.Header.Get(aHeader))
+	if !ok {
+		return se.New(errors.New(`missing authentication data`), 2)
+	}
+
+	pwHash, err := aFind(user)
+	if nil != err {
+		// Spend the same time for unknown users as for known ones
+		// to not reveal which usernames are valid.
+		if err = v.verifyDummy(aCtx, pass); isVerifyAbort(err) {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+
+	if err = v.verify(aCtx, user, pwHash, pass); nil != err {
+		if isVerifyAbort(err) {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+
+	// Store the user info so others can check for it
+	aRequest.URL.User = url.User(user)
+
+	return nil
+} // authenticate()
+
+// `matches()` checks whether `aPassword` of `aUser` matches the
+// password hash returned by `aFind`.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aUser`: The username to lookup.
+//   - `aPassword`: The (unhashed) password to check.
+//   - `aFind`: The function returning a user's password hash.
+//
+// Returns:
+//   - `bool`: `true` if a match was found, or `false` otherwise.
+//   - `error`: A reason why the verification couldn't be completed.
+func (v tVerifier) matches(aCtx context.Context, aUser, aPassword string, aFind tFindFunc) (bool, error) {
+	if aUser = strings.TrimSpace(aUser); "" == aUser {
+		return false, nil
+	}
+	if aPassword = strings.TrimSpace(aPassword); "" == aPassword {
+		return false, nil
+	}
+
+	pwHash, err := aFind(aUser)
+	if nil != err {
+		if err = v.verifyDummy(aCtx, aPassword); isVerifyAbort(err) {
+			return false, err
+		}
+		return false, nil
+	}
+
+	err = v.verify(aCtx, aUser, pwHash, aPassword)
+	if (nil != err) && isVerifyAbort(err) {
+		return false, err
+	}
+
+	return (nil == err), nil
+} // matches()
+
+// `verify()` checks whether `aPassword` matches `aHash` of `aUser`.
+//
+// Credentials found in the cache are accepted at once; otherwise
+// the comparison is subject to the verification limiter and
+// successfully verified credentials are cached.
+//
+// The comparison itself can't be interrupted, so it's run in its
+// own goroutine while waiting for either its result or `aCtx`
+// being done.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aUser`: The username to check.
+//   - `aHash`: The user's stored password hash.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `error`: `nil` on success, a mismatch or an abort error.
+func (v tVerifier) verify(aCtx context.Context, aUser, aHash, aPassword string) error {
+	if v.cache.lookup(aUser, aPassword, aHash) {
+		return nil
+	}
+
+	vl := v.verifyLimiter()
+	if err := vl.acquire(aCtx); nil != err {
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		defer vl.release()
+
+		err := bcrypt.CompareHashAndPassword([]byte(aHash), []byte(aPassword+pwPepper))
+		if nil == err {
+			v.cache.store(aUser, aPassword, aHash)
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-aCtx.Done():
+		return contextError(aCtx)
+	}
+} // verify()
+
+// `verifyDummy()` compares `aPassword` with the hash of a random
+// password thus spending the same time as a real verification.
+//
+// Parameters:
+//   - `aCtx`: The context controlling the verification.
+//   - `aPassword`: The (unhashed) password to check.
+//
+// Returns:
+//   - `error`: A mismatch or an abort error.
+func (v tVerifier) verifyDummy(aCtx context.Context, aPassword string) error {
+	dummy := tVerifier{limiter: v.limiter} // don't use the cache
+
+	return dummy.verify(aCtx, "", dummyHash(), aPassword)
+} // verifyDummy()
+
+// `verifyLimiter()` returns the verification limiter to use.
+//
+// Returns:
+//   - `*TVerifyLimiter`: The own or the package-wide limiter.
+func (v tVerifier) verifyLimiter() *TVerifyLimiter {
+	if nil != v.limiter {
+		return v.limiter
+	}
+
+	return pwLimiter.Load()
+} // verifyLimiter()
+
+// --------------------------------------------------------------------------
+
+// `dummyHash()` returns the hash of a random password using the
+// same algorithm and cost as real password hashes.
+//
+// Returns:
+//   - `string`: The dummy password hash.
+func dummyHash() string {
+	pwDummyHashOnce.Do(func() {
+		pw := make([]byte, 32)
+		_, _ = rand.Read(pw)
+		hash, _ := bcrypt.GenerateFromPassword(pw, pwCost)
+		pwDummyHash = string(hash)
+	})
+
+	return pwDummyHash
+} // dummyHash()
+
+/* _EoF_ */